@@ -2,84 +2,576 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/felixge/httpsnoop"
-	"github.com/phsym/console-slog"
+	"github.com/getkin/kin-openapi/openapi3filter"
 
+	"github.com/jqdurham/rest-sample/internal/accesslog"
+	"github.com/jqdurham/rest-sample/internal/admin"
 	"github.com/jqdurham/rest-sample/internal/api"
 	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/config"
+	"github.com/jqdurham/rest-sample/internal/cors"
+	"github.com/jqdurham/rest-sample/internal/health"
+	"github.com/jqdurham/rest-sample/internal/lifecycle"
+	"github.com/jqdurham/rest-sample/internal/listener"
+	"github.com/jqdurham/rest-sample/internal/loadshed"
+	"github.com/jqdurham/rest-sample/internal/logging"
+	"github.com/jqdurham/rest-sample/internal/metrics"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/policy"
 	"github.com/jqdurham/rest-sample/internal/post"
+	"github.com/jqdurham/rest-sample/internal/problem"
+	"github.com/jqdurham/rest-sample/internal/ratelimit"
+	"github.com/jqdurham/rest-sample/internal/recovery"
+	"github.com/jqdurham/rest-sample/internal/redact"
+	"github.com/jqdurham/rest-sample/internal/requestid"
+	"github.com/jqdurham/rest-sample/internal/session"
+	"github.com/jqdurham/rest-sample/internal/systemd"
+	"github.com/jqdurham/rest-sample/internal/tlsconfig"
+	"github.com/jqdurham/rest-sample/internal/token"
+	"github.com/jqdurham/rest-sample/internal/tracing"
+	"github.com/jqdurham/rest-sample/internal/upgrade"
 	"github.com/jqdurham/rest-sample/internal/user"
 	middleware "github.com/oapi-codegen/nethttp-middleware"
 )
 
+// Listener roles, under which listeners are handed to the new binary on SIGUSR2. The socket passed by systemd that
+// serves the admin endpoints is named roleAdmin with FileDescriptorName=.
+const (
+	roleAPI      = "api"
+	roleAdmin    = "admin"
+	roleRedirect = "redirect"
+)
+
 //go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen --config=../../.oapi-codegen.yaml ../../docs/openapi.json
 
 func main() {
+	cfg, printConfig, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if printConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			fatal(err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
 	defer func(start time.Time) {
 		slog.Info("Application shutdown", "uptime", time.Since(start))
 	}(time.Now())
 
-	logr := slog.New(console.NewHandler(os.Stderr, &console.HandlerOptions{Level: slog.LevelDebug}))
-	slog.SetDefault(logr)
+	logLevel, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		fatal(err)
+	}
+	// baseLevel is the configured level, level the one in effect, which SIGUSR1 and the admin listener change.
+	baseLevel, level := new(slog.LevelVar), new(slog.LevelVar)
+	baseLevel.Set(logLevel)
+	level.Set(logLevel)
+	logOut := os.Stderr
+	if cfg.Log.File != "" {
+		if logOut, err = os.OpenFile(cfg.Log.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640); err != nil {
+			fatal(err)
+		}
+		defer logOut.Close()
+	}
+	redactor, err := redact.New(cfg.Redact)
+	if err != nil {
+		fatal(err)
+	}
+	logHandler, err := logging.NewHandler(logOut, logging.Options{
+		Format:   cfg.Log.Format,
+		Level:    level,
+		NoColor:  cfg.Log.File != "",
+		Redactor: redactor,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	slog.SetDefault(slog.New(logHandler))
 
 	slog.Info("Application starting")
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	defer stop()
 
-	var addr string
-	flag.StringVar(&addr, "addr", ":8080", "Server listen address")
-	flag.Parse()
+	swagger, err := oapi.GetSwagger()
+	if err != nil {
+		fatal(err)
+	}
+
+	// https://github.com/oapi-codegen/oapi-codegen/issues/882
+	swagger.Servers = nil
+
+	pol, err := loadPolicy(cfg.Auth.PolicyFile, operation.IDs(swagger))
+	if err != nil {
+		fatal(err)
+	}
+	enforcer := policy.NewEnforcer(pol)
+
+	limits, err := loadRateLimits(cfg.RateLimit.File, operation.IDs(swagger))
+	if err != nil {
+		fatal(err)
+	}
+	limiter := ratelimit.NewLimiter(limits)
+
+	corsPolicy := cors.NewPolicy(cfg.CORS)
 
 	userSvc := user.NewService()
-	postSvc := post.NewService(userSvc)
-	srvHandler := api.NewServerHandler(userSvc, postSvc)
+	postSvc := post.NewService(userSvc, cfg.PostQuota)
+	sessionSvc := session.NewService(session.DefaultTTL, session.DefaultIdleTimeout)
+	tokenSvc := token.NewService()
+	srvHandler := api.NewServerHandler(userSvc, postSvc, sessionSvc, tokenSvc, enforcer)
+
+	registry := metrics.NewRegistry()
+	registry.MustRegister(userSvc.Collectors()...)
+	registry.MustRegister(postSvc.Collectors()...)
+
+	lc := lifecycle.New()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Config)
+	if err != nil {
+		fatal(err)
+	}
+
+	// Background workers outlive the signal context, so that they keep running while requests drain.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var (
+		workers        sync.WaitGroup
+		stoppedWorkers atomic.Int32
+	)
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+			if workerCtx.Err() == nil {
+				stoppedWorkers.Add(1)
+			}
+		}()
+	}
+	lc.OnShutdown("background workers", func(ctx context.Context) error {
+		stopWorkers()
+		done := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	// The reloader swaps the settings that may change while running; see config.Reloader for which ones.
+	var reloader *config.Reloader
+	reloader = config.NewReloader(cfg, func() (*config.Config, error) {
+		next, _, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+		return next, err
+	}, func(next *config.Config) error {
+		pol, err := loadPolicy(next.Auth.PolicyFile, operation.IDs(swagger))
+		if err != nil {
+			return err
+		}
+		limits, err := loadRateLimits(next.RateLimit.File, operation.IDs(swagger))
+		if err != nil {
+			return err
+		}
+		if next.Log.Level != reloader.Current().Log.Level {
+			// Validated by config.Load.
+			l, _ := logging.ParseLevel(next.Log.Level)
+			baseLevel.Set(l)
+			level.Set(l)
+		}
+		enforcer.Swap(pol)
+		limiter.Swap(limits)
+		corsPolicy.Swap(next.CORS)
+		postSvc.SetQuota(next.PostQuota)
+		return nil
+	})
+
+	var access *accesslog.Logger
+	if cfg.AccessLog.File != "" {
+		if access, err = accesslog.New(cfg.AccessLog.Config, redactor); err != nil {
+			fatal(err)
+		}
+		// SIGHUP reopens the access log after external tools moved it.
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		runWorker(func(ctx context.Context) { access.Run(ctx, hup, cfg.AccessLog.RotateInterval) })
+		lc.OnShutdown("access log", func(_ context.Context) error { return access.Close() })
+	}
+
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	runWorker(func(ctx context.Context) { logging.ToggleDebug(ctx, usr1, level, baseLevel) })
+	// SIGHUP, which also reopens the access log, or a change of the config file reloads the configuration.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	runWorker(func(ctx context.Context) { reloader.Run(ctx, reload, 10*time.Second) })
+	runWorker(func(ctx context.Context) { sessionSvc.Run(ctx, time.Minute) })
+	runWorker(func(ctx context.Context) { userSvc.Run(ctx, time.Minute) })
+	runWorker(func(ctx context.Context) { limiter.Run(ctx, time.Minute) })
+
+	// The token authenticator must precede the JWT authenticator, both read bearer tokens.
+	authenticators := []auth.Authenticator{
+		auth.NewSessionAuthenticator(sessionSvc),
+		auth.NewTokenAuthenticator(tokenSvc),
+	}
+	if cfg.Auth.JWKSFile != "" {
+		keys, err := auth.NewKeySet(cfg.Auth.JWKSFile)
+		if err != nil {
+			fatal(err)
+		}
+		runWorker(func(ctx context.Context) { keys.Watch(ctx, 10*time.Second) })
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(auth.NewVerifier(keys, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience, time.Minute)))
+	} else {
+		slog.Warn("No JWKS file configured, JWT bearer tokens will be rejected")
+	}
+
+	var certs *tlsconfig.Certificates
+	if cfg.Server.TLS.Enabled() {
+		if certs, err = tlsconfig.NewCertificates(cfg.Server.TLS); err != nil {
+			fatal(err)
+		}
+		runWorker(func(ctx context.Context) { certs.Watch(ctx, 10*time.Second) })
+		// Credentials sent with the request take precedence over the client certificate of the connection.
+		if cfg.Server.TLS.ClientCA != "" {
+			authenticators = append(authenticators, auth.NewCertificateAuthenticator())
+		}
+	}
+
+	checker := health.NewChecker(health.DefaultCheckTimeout)
+	checker.Register("lifecycle", func(_ context.Context) error {
+		if !lc.Ready() {
+			return errors.New("not accepting traffic")
+		}
+		return nil
+	})
+	checker.Register("workers", func(_ context.Context) error {
+		if n := stoppedWorkers.Load(); n > 0 {
+			return fmt.Errorf("%d background workers stopped unexpectedly", n)
+		}
+		return nil
+	})
+
+	maintenance := admin.NewMaintenance()
 
 	router := http.NewServeMux()
-	oapi.HandlerFromMux(srvHandler, router)
+	oapi.HandlerWithOptions(srvHandler, oapi.StdHTTPServerOptions{
+		BaseRouter:  router,
+		Middlewares: []oapi.MiddlewareFunc{tracing.Handler},
+		// Parameters the validator accepted but the generated wrapper cannot bind, answered like validation errors.
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			problem.Error(w, r, http.StatusBadRequest, redactor.Text(err.Error()))
+		},
+	})
 
-	swagger, err := oapi.GetSwagger()
+	resolveOperation, err := operation.Middleware(swagger)
 	if err != nil {
 		fatal(err)
 	}
 
-	// https://github.com/oapi-codegen/oapi-codegen/issues/882
-	swagger.Servers = nil
+	h := tracing.Stage("policy", tracing.PhaseValidation, policy.Middleware(enforcer, userRoles(userSvc)))(router)
+	h = tracing.Stage("validate request", tracing.PhaseValidation, middleware.OapiRequestValidatorWithOptions(swagger, &middleware.Options{
+		Options: openapi3filter.Options{
+			AuthenticationFunc: auth.ValidateSecurity,
+		},
+		ErrorHandler: validationError(redactor),
+	}))(h)
+	h = tracing.Stage("decode body", tracing.PhaseValidation, api.DecodeBody)(h)
+	h = tracing.Stage("rate limit", "", ratelimit.Middleware(limiter))(h)
+	h = tracing.Stage("authenticate", "", auth.Middleware(authenticators...))(h)
+	h = tracing.Stage("rate limit authentication", "", ratelimit.AuthFailures(limiter))(h)
+	h = api.LimitBody(cfg.Server.MaxBodyBytes)(h)
 
-	h := middleware.OapiRequestValidator(swagger)(router)
-	h = logRequestHandler(h)
+	// Probes and metrics are served outside of the API chain, so that they are neither authenticated nor validated
+	// against the spec.
+	ops := http.NewServeMux()
+	ops.HandleFunc("GET /healthz", health.Liveness)
+	ops.HandleFunc("GET /readyz", checker.Readiness)
+	ops.HandleFunc("GET /version", health.VersionHandler)
+	ops.Handle("GET /metrics", metrics.Handler(registry))
+	ops.Handle("/", maintenance.Middleware(time.Minute)(h))
+	h = ops
+
+	if cfg.LoadShed.MaxInFlight > 0 {
+		shedder := loadshed.NewLimiter(cfg.LoadShed)
+		registry.MustRegister(shedder.Collectors()...)
+		// Probes must keep answering while the server sheds load.
+		h = loadshed.Middleware(shedder, time.Second, "/healthz", "/readyz", "/metrics")(h)
+	}
+	methods, err := operation.Methods(swagger)
+	if err != nil {
+		fatal(err)
+	}
+	h = corsPolicy.Middleware(methods)(h)
+	h = recovery.Middleware(registry)(h)
+	h = logRequestHandler(h, access)
+	h = metrics.NewHTTP(registry).Middleware(h)
+	h = tracing.Middleware(cfg.Tracing.ServerTiming)(h)
+	h = resolveOperation(h)
+	h = requestid.Middleware(h)
+	h = lc.Middleware(h)
 
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: h,
-		BaseContext: func(_ net.Listener) context.Context {
-			return ctx
+	adminHandler := admin.Handler(admin.Options{
+		Config: func() any {
+			values, err := reloader.Current().Values()
+			if err != nil {
+				return err.Error()
+			}
+			return redactor.Value(values)
 		},
-		ReadHeaderTimeout: 5 * time.Second,
+		Stats: map[string]func() any{
+			"users":    func() any { return userSvc.Stats() },
+			"posts":    func() any { return postSvc.Stats() },
+			"sessions": func() any { return sessionSvc.Stats() },
+			"tokens":   func() any { return tokenSvc.Stats() },
+		},
+		Level:       level,
+		Maintenance: maintenance,
+	})
+	// stores are handed over to the new binary on SIGUSR2.
+	stores := map[string]upgrade.Store{"users": userSvc, "posts": postSvc, "sessions": sessionSvc, "tokens": tokenSvc}
+
+	// A process upgraded on SIGUSR2 inherits the listeners of the one it replaces. Otherwise sockets passed by
+	// systemd replace the configured addresses: the one named roleAdmin serves the admin endpoints, all others the
+	// API.
+	parent, err := upgrade.Inherited()
+	if err != nil {
+		fatal(err)
+	}
+	lns := make(map[string][]net.Listener)
+	if parent != nil {
+		lns = parent.Listeners
+	} else {
+		activated, err := systemd.Listeners()
+		if err != nil {
+			fatal(err)
+		}
+		lns[roleAdmin] = activated[roleAdmin]
+		delete(activated, roleAdmin)
+		for _, l := range activated {
+			lns[roleAPI] = append(lns[roleAPI], l...)
+		}
 	}
+	if len(lns[roleAPI]) == 0 {
+		if lns[roleAPI], err = listener.ListenAll(cfg.Server.Addrs, cfg.Server.Listener()); err != nil {
+			fatal(err)
+		}
+	}
+	if len(lns[roleAdmin]) == 0 && cfg.Server.AdminAddr != "" {
+		ln, err := listener.Listen(cfg.Server.AdminAddr, cfg.Server.Listener())
+		if err != nil {
+			fatal(err)
+		}
+		lns[roleAdmin] = []net.Listener{ln}
+	}
+	if len(lns[roleRedirect]) == 0 && cfg.Server.TLS.RedirectAddr != "" {
+		ln, err := listener.Listen(cfg.Server.TLS.RedirectAddr, cfg.Server.Listener())
+		if err != nil {
+			fatal(err)
+		}
+		lns[roleRedirect] = []net.Listener{ln}
+	}
+
+	// serve starts the servers on the listeners, keyed by role, and returns them.
+	serve := func(lns map[string][]net.Listener) []lifecycle.Server {
+		srv := &http.Server{
+			Handler:           h,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			ReadTimeout:       cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+			MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		}
+		servers := []lifecycle.Server{{HTTP: srv, Addrs: listenerAddrs(lns[roleAPI])}}
+
+		if redirectLns := lns[roleRedirect]; len(redirectLns) > 0 {
+			redirectSrv := &http.Server{
+				Handler:           tlsconfig.Redirect(tcpPort(lns[roleAPI])),
+				ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+				IdleTimeout:       cfg.Server.IdleTimeout,
+			}
+			servers = append(servers, lifecycle.Server{HTTP: redirectSrv, Addrs: listenerAddrs(redirectLns)})
 
-	go func() {
-		slog.Info("API server starting", "addr", addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.Error("API server error", "error", err)
-			stop()
+			for _, ln := range redirectLns {
+				go func() {
+					slog.Info("HTTPS redirect server starting", "addr", ln.Addr().String())
+					if err := redirectSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						slog.Error("HTTPS redirect server error", "error", err)
+					}
+				}()
+			}
 		}
-	}()
 
-	<-ctx.Done()
+		if adminLns := lns[roleAdmin]; len(adminLns) > 0 {
+			adminSrv := &http.Server{
+				Handler:           adminHandler,
+				ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+				IdleTimeout:       cfg.Server.IdleTimeout,
+			}
+			servers = append(servers, lifecycle.Server{HTTP: adminSrv, Addrs: listenerAddrs(adminLns)})
+
+			for _, ln := range adminLns {
+				go func() {
+					slog.Info("Admin server starting", "addr", ln.Addr().String())
+					if err := adminSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						slog.Error("Admin server error", "error", err)
+					}
+				}()
+			}
+		}
+
+		for _, ln := range lns[roleAPI] {
+			if certs != nil {
+				ln = tls.NewListener(ln, certs.TLSConfig())
+			}
+			go func() {
+				slog.Info("API server starting", "addr", ln.Addr().String(), "tls", certs != nil)
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("API server error", "error", err)
+					stop()
+				}
+			}()
+		}
+		return servers
+	}
+
+	if parent != nil {
+		// Blocks until the parent stopped serving, so that no request sees the state before it was handed over.
+		if err := parent.Restore(stores); err != nil {
+			fatal(err)
+		}
+		// The watchdog follows MAINPID to this process.
+		if os.Getenv("WATCHDOG_PID") != "" {
+			os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+		}
+	}
+	servers := serve(lns)
+	lc.SetReady(true)
+
+	if interval, ok := systemd.WatchdogInterval(); ok {
+		runWorker(func(ctx context.Context) {
+			systemd.RunWatchdog(ctx, interval, func() bool { return stoppedWorkers.Load() == 0 })
+		})
+	}
+	if parent != nil {
+		if err := parent.Ready(); err != nil {
+			slog.Warn("Upgrade parent not notified", "error", err)
+		}
+		// systemd supervises this process once the parent exits; it accepts the notification with NotifyAccess=all.
+		notify(fmt.Sprintf("MAINPID=%d\n%s", os.Getpid(), systemd.Ready))
+	} else {
+		notify(systemd.Ready)
+	}
+
+	// SIGUSR2 upgrades to the binary now found at the path this one was started from.
+	usr2 := make(chan os.Signal, 1)
+	signal.Notify(usr2, syscall.SIGUSR2)
+
+	upgraded := false
+	for !upgraded && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-usr2:
+			slog.Info("Upgrade requested")
+			quiesced := false
+			err := upgrade.Run(upgrade.Handoff{
+				Listeners: lns,
+				Stores:    stores,
+				Timeout:   cfg.Server.UpgradeTimeout,
+				Quiesce: func() {
+					quiesced = true
+					abandoned, _ := lc.Drain(reloader.Current().Shutdown.DrainTimeout, servers...)
+					slog.Info("Connections drained for upgrade", "abandoned", abandoned)
+				},
+				Resume: func(resumed map[string][]net.Listener) {
+					quiesced = false
+					lns = resumed
+					servers = serve(lns)
+				},
+			})
+			if err == nil {
+				upgraded = true
+				break
+			}
+			slog.Error("Upgrade failed", "error", err)
+			if quiesced {
+				// The listeners could not be restored.
+				stop()
+			}
+		}
+	}
+	// Restore default signal handling, so that a second signal terminates the process immediately.
+	stop()
+
+	shutdown := reloader.Current().Shutdown
+	if upgraded {
+		// The new process accepts connections already, and the servers are drained.
+		shutdown.PreStopDelay = 0
+	} else {
+		notify(systemd.Stopping)
+	}
+	lc.Shutdown(shutdown, servers...)
+}
+
+// notify reports a state change to systemd, if it supervises the process.
+func notify(state string) {
+	if ok, err := systemd.Notify(state); err != nil {
+		slog.Warn("systemd notification failed", "state", state, "error", err)
+	} else if ok {
+		slog.Debug("systemd notified", "state", state)
+	}
+}
+
+// tcpPort returns the port of the first TCP listener, empty if there is none.
+func tcpPort(lns []net.Listener) string {
+	for _, ln := range lns {
+		if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+			return strconv.Itoa(addr.Port)
+		}
+	}
+	return ""
+}
+
+// listenerAddrs returns the addresses of the listeners.
+func listenerAddrs(lns []net.Listener) []string {
+	addrs := make([]string, len(lns))
+	for i, ln := range lns {
+		addrs[i] = ln.Addr().String()
+	}
+	return addrs
 }
 
-func logRequestHandler(h http.Handler) http.Handler {
+// logRequestHandler logs each request and, when access is not nil, writes it to the access log.
+func logRequestHandler(h http.Handler, access *accesslog.Logger) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		var (
 			level   = slog.LevelInfo
@@ -102,10 +594,69 @@ func logRequestHandler(h http.Handler) http.Handler {
 			slog.String("ua", r.UserAgent()),
 			slog.String("ip", r.RemoteAddr),
 		)
+		if access != nil {
+			access.Log(r, w.Header(), metrics)
+		}
 	}
 	return http.HandlerFunc(fn)
 }
 
+// validationError answers requests rejected by the OpenAPI validator. Its messages may quote the offending
+// values, so email addresses are masked.
+func validationError(red *redact.Redactor) func(w http.ResponseWriter, message string, statusCode int) {
+	return func(w http.ResponseWriter, message string, statusCode int) {
+		if statusCode == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		problem.Write(w, nil, problem.New(statusCode, red.Text(message)))
+	}
+}
+
+func loadPolicy(path string, operationIDs []string) (*policy.Policy, error) {
+	load := policy.Default
+	if path != "" {
+		load = func() (*policy.Policy, error) { return policy.Load(path) }
+	}
+
+	p, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(operationIDs); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func loadRateLimits(path string, operationIDs []string) (*ratelimit.Config, error) {
+	load := ratelimit.Default
+	if path != "" {
+		load = func() (*ratelimit.Config, error) { return ratelimit.Load(path) }
+	}
+
+	c, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(operationIDs); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// userRoles resolves the role recorded for a local user.
+func userRoles(userSvc user.Servicer) policy.RoleResolver {
+	return func(ctx context.Context, userID int64) []string {
+		usr, err := userSvc.GetUser(ctx, userID)
+		if err != nil || usr.Role == "" {
+			return nil
+		}
+		return []string{usr.Role}
+	}
+}
+
 func fatal(err error) {
 	slog.Error(err.Error())
 	os.Exit(1)
@@ -0,0 +1,344 @@
+// Package accesslog writes a classic access log, in Apache Combined Log Format or a custom format, to a file
+// that is rotated by size and age.
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/jqdurham/rest-sample/internal/redact"
+)
+
+// Predefined formats, using Apache mod_log_config directives.
+const (
+	Common   = `%h %l %u %t "%r" %>s %b`
+	Combined = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// Config configures the access log.
+type Config struct {
+	// File is the path of the access log.
+	File string `yaml:"file"`
+	// Format is common, combined or a format string of mod_log_config directives.
+	Format string `yaml:"format"`
+	// MaxSizeMB is the size at which the file is rotated.
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxAge is how long rotated files are kept, rounded up to whole days; 0 keeps them.
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxBackups is the number of rotated files kept; 0 keeps them all.
+	MaxBackups int `yaml:"max_backups"`
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress"`
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+// entry is what a directive is rendered from.
+type entry struct {
+	r      *http.Request
+	header http.Header
+	m      httpsnoop.Metrics
+	start  time.Time
+	redact *redact.Redactor
+}
+
+func (e *entry) url(s string) string {
+	if e.redact == nil {
+		return s
+	}
+	return e.redact.URL(s)
+}
+
+type directive func(b *strings.Builder, e *entry)
+
+// Logger writes one line per request.
+type Logger struct {
+	mu     sync.Mutex
+	out    *lumberjack.Logger
+	format []directive
+	redact *redact.Redactor
+}
+
+// New opens the access log described by cfg. When red is not nil, URLs, headers and client addresses are
+// redacted with it.
+func New(cfg Config, red *redact.Redactor) (*Logger, error) {
+	if cfg.File == "" {
+		return nil, &InvalidError{message: "access log file is required"}
+	}
+
+	format, err := parse(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{
+		out: &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     maxAgeDays(cfg.MaxAge),
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		},
+		format: format,
+		redact: red,
+	}, nil
+}
+
+// maxAgeDays converts d to the days lumberjack keeps rotated files for. It rounds up, as 0 days would keep them
+// forever.
+func maxAgeDays(d time.Duration) int {
+	return int(math.Ceil(d.Hours() / 24))
+}
+
+// Log writes the line for a request served with the response header and metrics captured by httpsnoop.
+func (l *Logger) Log(r *http.Request, header http.Header, m httpsnoop.Metrics) {
+	e := &entry{r: r, header: header, m: m, start: time.Now().Add(-m.Duration), redact: l.redact}
+	if l.redact != nil {
+		e.header = l.redact.Header(header)
+		r = r.Clone(r.Context())
+		r.Header = l.redact.Header(r.Header)
+		e.r = r
+	}
+
+	var b strings.Builder
+	for _, d := range l.format {
+		d(&b, e)
+	}
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.out, b.String())
+}
+
+// Reopen closes the file, which is opened again by the next write. This lets external tools such as logrotate
+// move the file away.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.Close()
+}
+
+// Rotate moves the current file aside and starts a new one.
+func (l *Logger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.Rotate()
+}
+
+func (l *Logger) Close() error {
+	return l.Reopen()
+}
+
+// Run reopens the file whenever a signal is received and, when interval is positive, rotates it at that
+// interval, until ctx is done.
+func (l *Logger) Run(ctx context.Context, signals <-chan os.Signal, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := l.Reopen(); err != nil {
+				slog.ErrorContext(ctx, "access log reopen failed", slog.String("error", err.Error()))
+			}
+		case <-tick:
+			if err := l.Rotate(); err != nil {
+				slog.ErrorContext(ctx, "access log rotation failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// parse compiles a format of mod_log_config directives. Supported are %h, %l, %u, %t, %r, %m, %U, %q, %H, %s,
+// %>s, %b, %B, %D, %T, %{Name}i and %{Name}o, as well as %%.
+func parse(format string) ([]directive, error) {
+	switch format {
+	case "", "combined":
+		format = Combined
+	case "common":
+		format = Common
+	}
+
+	var (
+		out     []directive
+		literal strings.Builder
+	)
+	flush := func() {
+		if literal.Len() > 0 {
+			s := literal.String()
+			out = append(out, func(b *strings.Builder, _ *entry) { b.WriteString(s) })
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return nil, &InvalidError{message: "access log format ends with %"}
+		}
+
+		var arg string
+		if format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, &InvalidError{message: "unterminated %{ in access log format"}
+			}
+			arg = format[i+1 : i+end]
+			i += end + 1
+			if i >= len(format) {
+				return nil, &InvalidError{message: fmt.Sprintf("missing directive after %%{%s}", arg)}
+			}
+		}
+		if format[i] == '>' {
+			i++
+			if i >= len(format) || format[i] != 's' {
+				return nil, &InvalidError{message: "only %>s may use the > modifier"}
+			}
+		}
+
+		if format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+
+		d, err := directiveFor(format[i], arg)
+		if err != nil {
+			return nil, err
+		}
+		flush()
+		out = append(out, d)
+	}
+	flush()
+
+	return out, nil
+}
+
+func directiveFor(c byte, arg string) (directive, error) {
+	switch c {
+	case 'h':
+		return func(b *strings.Builder, e *entry) {
+			host := remoteHost(e.r.RemoteAddr)
+			if e.redact != nil {
+				host = e.redact.IP(host)
+			}
+			b.WriteString(host)
+		}, nil
+	case 'l', 'u':
+		// Neither identd nor HTTP authentication names are known at this point.
+		return func(b *strings.Builder, _ *entry) { b.WriteByte('-') }, nil
+	case 't':
+		return func(b *strings.Builder, e *entry) {
+			b.WriteString(e.start.Format("[02/Jan/2006:15:04:05 -0700]"))
+		}, nil
+	case 'r':
+		return func(b *strings.Builder, e *entry) {
+			b.WriteString(escape(e.r.Method + " " + e.url(e.r.URL.RequestURI()) + " " + e.r.Proto))
+		}, nil
+	case 'm':
+		return func(b *strings.Builder, e *entry) { b.WriteString(escape(e.r.Method)) }, nil
+	case 'U':
+		return func(b *strings.Builder, e *entry) { b.WriteString(escape(e.url(e.r.URL.Path))) }, nil
+	case 'q':
+		return func(b *strings.Builder, e *entry) {
+			if e.r.URL.RawQuery != "" {
+				b.WriteString(escape(e.url("?" + e.r.URL.RawQuery)))
+			}
+		}, nil
+	case 'H':
+		return func(b *strings.Builder, e *entry) { b.WriteString(e.r.Proto) }, nil
+	case 's':
+		return func(b *strings.Builder, e *entry) { b.WriteString(strconv.Itoa(e.m.Code)) }, nil
+	case 'b':
+		return func(b *strings.Builder, e *entry) {
+			if e.m.Written == 0 {
+				b.WriteByte('-')
+				return
+			}
+			b.WriteString(strconv.FormatInt(e.m.Written, 10))
+		}, nil
+	case 'B':
+		return func(b *strings.Builder, e *entry) { b.WriteString(strconv.FormatInt(e.m.Written, 10)) }, nil
+	case 'D':
+		return func(b *strings.Builder, e *entry) { b.WriteString(strconv.FormatInt(e.m.Duration.Microseconds(), 10)) }, nil
+	case 'T':
+		return func(b *strings.Builder, e *entry) {
+			b.WriteString(strconv.FormatInt(int64(e.m.Duration.Seconds()), 10))
+		}, nil
+	case 'i', 'o':
+		if arg == "" {
+			return nil, &InvalidError{message: fmt.Sprintf("%%%c requires a header name, e.g. %%{User-Agent}%c", c, c)}
+		}
+		name := http.CanonicalHeaderKey(arg)
+		return func(b *strings.Builder, e *entry) {
+			h := e.r.Header
+			if c == 'o' {
+				h = e.header
+			}
+			if v := h.Get(name); v != "" {
+				b.WriteString(escape(v))
+				return
+			}
+			b.WriteByte('-')
+		}, nil
+	default:
+		return nil, &InvalidError{message: fmt.Sprintf("unsupported access log directive %%%c", c)}
+	}
+}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// escape quotes characters that would break the line apart or its quoted fields, as Apache does.
+func escape(s string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { return r == '"' || r == '\\' || r < 0x20 || r == 0x7f }) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+)
+
+func TestLogger_Log(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Combined",
+			format: "combined",
+			want: `^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+				`"GET /posts\?page=2 HTTP/1\.1" 200 42 "-" "curl/8\.0 \\"quoted\\""` + "\n$",
+		},
+		{
+			name:   "Custom",
+			format: `%m %U%q %s %{X-Request-ID}o %D%%`,
+			want:   `^GET /posts\?page=2 200 abc 1500%` + "\n$",
+		},
+		{
+			name:    "Unsupported directive",
+			format:  `%h %Z`,
+			wantErr: true,
+		},
+		{
+			name:    "Header directive without name",
+			format:  `%i`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			file := filepath.Join(t.TempDir(), "access.log")
+			l, err := New(Config{File: file, Format: tt.format}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/posts?page=2", nil)
+			r.Header.Set("User-Agent", `curl/8.0 "quoted"`)
+			header := http.Header{"X-Request-Id": {"abc"}}
+			l.Log(r, header, httpsnoop.Metrics{Code: http.StatusOK, Duration: 1500 * time.Microsecond, Written: 42})
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("read access log: %v", err)
+			}
+			if !regexp.MustCompile(tt.want).Match(got) {
+				t.Errorf("Log() wrote %q, want match for %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxAgeDays(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		age  time.Duration
+		want int
+	}{
+		{age: 0, want: 0},
+		{age: time.Hour, want: 1},
+		{age: 24 * time.Hour, want: 1},
+		{age: 36 * time.Hour, want: 2},
+		{age: 28 * 24 * time.Hour, want: 28},
+	}
+	for _, tt := range tests {
+		if got := maxAgeDays(tt.age); got != tt.want {
+			t.Errorf("maxAgeDays(%s) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package admin serves the operational endpoints of the admin listener: profiling, expvars, the effective
+// configuration, store statistics, the log level and maintenance mode. It is meant to be bound to localhost or a
+// unix socket and never goes through the public OpenAPI router.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/jqdurham/rest-sample/internal/logging"
+)
+
+// maxBodyBytes bounds the bodies of admin requests.
+const maxBodyBytes = 4 << 10
+
+// Options provide what the admin endpoints report and control.
+type Options struct {
+	// Config returns the effective configuration. It is served as returned, so it must mask secrets itself.
+	Config func() any
+	// Stats return statistics of the stores, keyed by store.
+	Stats map[string]func() any
+	// Level is the log level changed by PUT /loglevel.
+	Level *slog.LevelVar
+	// Maintenance is switched by PUT /maintenance.
+	Maintenance *Maintenance
+}
+
+// Handler serves the admin endpoints.
+func Handler(opts Options) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, opts.Config())
+	})
+
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, _ *http.Request) {
+		out := make(map[string]any, len(opts.Stats))
+		for name, fn := range opts.Stats {
+			out[name] = fn()
+		}
+		write(w, http.StatusOK, out)
+	})
+
+	mux.HandleFunc("GET /loglevel", func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, levelBody{Level: opts.Level.Level().String()})
+	})
+	mux.HandleFunc("PUT /loglevel", func(w http.ResponseWriter, r *http.Request) {
+		var body levelBody
+		if !decode(w, r, &body) {
+			return
+		}
+		level, err := logging.ParseLevel(body.Level)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Level.Set(level)
+		slog.WarnContext(r.Context(), "log level changed", slog.String("level", level.String()))
+		write(w, http.StatusOK, levelBody{Level: level.String()})
+	})
+
+	mux.HandleFunc("GET /maintenance", func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, opts.Maintenance.Status())
+	})
+	mux.HandleFunc("PUT /maintenance", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Enabled *bool  `json:"enabled"`
+			Message string `json:"message"`
+		}
+		if !decode(w, r, &body) {
+			return
+		}
+		if body.Enabled == nil {
+			writeError(w, http.StatusBadRequest, "enabled is required")
+			return
+		}
+		status := opts.Maintenance.Set(*body.Enabled, body.Message)
+		slog.WarnContext(r.Context(), "maintenance mode changed", slog.Bool("enabled", status.Enabled))
+		write(w, http.StatusOK, status)
+	})
+
+	return mux
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "request body must hold a single JSON value")
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	write(w, status, map[string]string{"error": msg})
+}
+
+func write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(body)
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_maintenance(t *testing.T) {
+	t.Parallel()
+	m := NewMaintenance()
+	h := Handler(Options{Level: new(slog.LevelVar), Maintenance: m})
+	api := m.Middleware(time.Minute, "/healthz")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantAPI    int
+	}{
+		{name: "Rejects a body without enabled", body: `{"message":"x"}`, wantStatus: http.StatusBadRequest, wantAPI: http.StatusNoContent},
+		{name: "Rejects unknown fields", body: `{"enabled":true,"x":1}`, wantStatus: http.StatusBadRequest, wantAPI: http.StatusNoContent},
+		{name: "Enables", body: `{"enabled":true,"message":"upgrading"}`, wantStatus: http.StatusOK, wantAPI: http.StatusServiceUnavailable},
+		{name: "Disables", body: `{"enabled":false}`, wantStatus: http.StatusOK, wantAPI: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		// Subtests share the maintenance mode, so they must not run in parallel.
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/maintenance", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("PUT /maintenance status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			rec = httptest.NewRecorder()
+			api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+			if rec.Code != tt.wantAPI {
+				t.Errorf("GET /users status = %d, want %d", rec.Code, tt.wantAPI)
+			}
+
+			rec = httptest.NewRecorder()
+			api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("GET /healthz status = %d, want %d", rec.Code, http.StatusNoContent)
+			}
+		})
+	}
+}
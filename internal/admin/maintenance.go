@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// MaintenanceStatus describes the maintenance mode.
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Maintenance holds whether the public API is down for maintenance.
+type Maintenance struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Set enables or disables maintenance mode. The message is returned to callers while it is enabled.
+func (m *Maintenance) Set(enabled bool, message string) MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case !enabled:
+		m.status = MaintenanceStatus{}
+	case !m.status.Enabled:
+		now := time.Now()
+		m.status = MaintenanceStatus{Enabled: true, Message: message, Since: &now}
+	default:
+		m.status.Message = message
+	}
+	return m.status
+}
+
+// Middleware answers requests with 503 and Retry-After while maintenance mode is enabled. Requests for the exempt
+// paths, such as health probes, are always served.
+func (m *Maintenance) Middleware(retryAfter time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := m.Status()
+			if !status.Enabled || slices.Contains(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			detail := status.Message
+			if detail == "" {
+				detail = "the service is down for maintenance"
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			problem.Error(w, r, http.StatusServiceUnavailable, detail)
+		})
+	}
+}
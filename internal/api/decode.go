@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// DefaultMaxBodyBytes is the default limit for request bodies.
+const DefaultMaxBodyBytes = 1 << 20
+
+// LimitBody rejects request bodies larger than maxBytes with 413. The body is read up front so that the limit
+// holds for every later reader, including request validation.
+func LimitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				payloadTooLarge(w, r, maxBytes)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+			if err != nil {
+				var mbe *http.MaxBytesError
+				if errors.As(err, &mbe) {
+					payloadTooLarge(w, r, maxBytes)
+					return
+				}
+				problem.Error(w, r, http.StatusBadRequest, "unable to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestBodies creates the value the JSON body of an operation is decoded into, keyed by operationId.
+var requestBodies = map[string]func() any{
+	"CreateUser":      func() any { return new(oapi.UserInput) },
+	"UpdateUser":      func() any { return new(oapi.UserInput) },
+	"SetUserPassword": func() any { return new(oapi.PasswordInput) },
+	"CreateUserToken": func() any { return new(oapi.TokenInput) },
+	"CreateSession":   func() any { return new(oapi.LoginInput) },
+	"CreatePost":      func() any { return new(oapi.PostInput) },
+	"UpdatePost":      func() any { return new(oapi.PostInput) },
+	"TransferPost":    func() any { return new(oapi.PostTransfer) },
+}
+
+// DecodeBody decodes JSON request bodies with decodeJSON ahead of request validation, so that malformed JSON, type
+// mismatches, unknown fields and trailing data are reported with their byte offset rather than by the validator.
+// It relies on the operation being resolved and the body being buffered by LimitBody.
+func DecodeBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		newBody, ok := requestBodies[operation.FromContext(r.Context())]
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		// Other media types are rejected by the validator.
+		if !ok || mediaType != "application/json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "unable to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if !decodeJSON(w, r, newBody()) {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeJSON decodes a request body holding exactly one JSON value without unknown fields into v. When it
+// fails, it writes the error response and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	body := &countingReader{r: r.Body}
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			payloadTooLarge(w, r, mbe.Limit)
+			return false
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("request body ends unexpectedly at byte offset %d", body.n))
+			return false
+		}
+		problem.Error(w, r, http.StatusBadRequest, decodeErrorMessage(err))
+		return false
+	}
+
+	end := dec.InputOffset()
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		msg := fmt.Sprintf("request body must hold a single JSON value, found more after byte offset %d", end)
+		problem.Error(w, r, http.StatusBadRequest, msg)
+		return false
+	}
+
+	return true
+}
+
+// countingReader counts the bytes read, which locates where a truncated body ends.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func decodeErrorMessage(err error) string {
+	var se *json.SyntaxError
+	var te *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &se):
+		return fmt.Sprintf("malformed JSON at byte offset %d: %s", se.Offset, se.Error())
+	case errors.As(err, &te):
+		if te.Field != "" {
+			return fmt.Sprintf("field %q must be of type %s, found %s at byte offset %d", te.Field, te.Type, te.Value, te.Offset)
+		}
+		return fmt.Sprintf("request body must be of type %s, found %s at byte offset %d", te.Type, te.Value, te.Offset)
+	case errors.Is(err, io.EOF):
+		return "request body must not be empty"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	default:
+		return err.Error()
+	}
+}
+
+func payloadTooLarge(w http.ResponseWriter, r *http.Request, maxBytes int64) {
+	problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not exceed %d bytes", maxBytes))
+}
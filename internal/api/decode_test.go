@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	middleware "github.com/oapi-codegen/nethttp-middleware"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/problem"
+	"github.com/jqdurham/rest-sample/internal/requestid"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+	type input struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name     string
+		body     string
+		want     bool
+		wantCode int
+		wantBody string
+	}{
+		{name: "Accepts single object", body: `{"name":"abc"}`, want: true, wantCode: http.StatusOK},
+		{
+			name:     "Rejects unknown field",
+			body:     `{"name":"abc","role":"admin"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `unknown field \"role\"`,
+		},
+		{name: "Rejects trailing value", body: `{"name":"abc"} {}`, wantCode: http.StatusBadRequest, wantBody: "found more after byte offset 14"},
+		{name: "Rejects trailing garbage", body: `{"name":"abc"}x`, wantCode: http.StatusBadRequest, wantBody: "found more after byte offset 14"},
+		{name: "Rejects malformed JSON", body: `{"name":}`, wantCode: http.StatusBadRequest, wantBody: "malformed JSON at byte offset 9"},
+		{
+			name:     "Rejects wrong type",
+			body:     `{"name":1}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `field \"name\" must be of type string, found number at byte offset 9`,
+		},
+		{name: "Rejects empty body", body: ``, wantCode: http.StatusBadRequest, wantBody: "request body must not be empty"},
+		{name: "Rejects truncated body", body: `{"name":"abc"`, wantCode: http.StatusBadRequest, wantBody: "request body ends unexpectedly at byte offset 13"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			w.Header().Set(requestid.Header, "req-1")
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+
+			var v input
+			if got := decodeJSON(w, r, &v); got != tt.want {
+				t.Errorf("decodeJSON() = %v, want %v", got, tt.want)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("decodeJSON() status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("decodeJSON() body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+			if !tt.want && !strings.Contains(w.Body.String(), `"request_id":"req-1"`) {
+				t.Errorf("decodeJSON() body = %s, want the request ID", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	t.Parallel()
+	h := LimitBody(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		if decodeJSON(w, r, &v) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{name: "Accepts body within limit", body: `{"a":1}`, want: http.StatusNoContent},
+		{name: "Rejects declared oversized body", body: `{"a":1234}`, want: http.StatusRequestEntityTooLarge},
+		{name: "Rejects chunked oversized body", body: `{"a":1234}`, chunked: true, want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("LimitBody() status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	t.Parallel()
+	swagger, err := oapi.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	swagger.Servers = nil
+	for id := range requestBodies {
+		if !slices.Contains(operation.IDs(swagger), id) {
+			t.Errorf("requestBodies names unknown operation %q", id)
+		}
+	}
+	resolveOperation, err := operation.Middleware(swagger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same order as the server: the body is limited and decoded before the request is validated.
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	h = middleware.OapiRequestValidatorWithOptions(swagger, &middleware.Options{
+		Options: openapi3filter.Options{
+			AuthenticationFunc: func(context.Context, *openapi3filter.AuthenticationInput) error { return nil },
+		},
+	})(h)
+	h = DecodeBody(h)
+	h = LimitBody(DefaultMaxBodyBytes)(h)
+	h = resolveOperation(h)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "Accepts valid body", body: `{"name":"Alice A","email":"alice@example.com"}`, wantCode: http.StatusCreated},
+		{name: "Reports truncated body", body: `{"name":"Alice A"`, wantCode: http.StatusBadRequest, wantBody: "ends unexpectedly at byte offset 17"},
+		{name: "Reports malformed JSON", body: `{"name":,}`, wantCode: http.StatusBadRequest, wantBody: "malformed JSON at byte offset 9"},
+		{
+			name:     "Reports type mismatch",
+			body:     `{"name":"Alice A","email":7}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `field \"email\" must be of type string, found number at byte offset 27`,
+		},
+		{name: "Reports unknown field", body: `{"name":"Alice A","email":"a@b.c","x":1}`, wantCode: http.StatusBadRequest, wantBody: `unknown field \"x\"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantBody == "" {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -3,10 +3,19 @@ package api
 import (
 	"encoding/json"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/policy"
 	"github.com/jqdurham/rest-sample/internal/post"
+	"github.com/jqdurham/rest-sample/internal/problem"
+	"github.com/jqdurham/rest-sample/internal/session"
+	"github.com/jqdurham/rest-sample/internal/token"
+	"github.com/jqdurham/rest-sample/internal/tracing"
 	"github.com/jqdurham/rest-sample/internal/user"
 )
 
@@ -14,18 +23,44 @@ import (
 var _ oapi.ServerInterface = &ServerHandler{}
 
 type ServerHandler struct {
-	userSvc user.Servicer
-	postSvc post.Servicer
+	userSvc    user.Servicer
+	postSvc    post.Servicer
+	sessionSvc session.Servicer
+	tokenSvc   token.Servicer
+	enforcer   *policy.Enforcer
 }
 
-func NewServerHandler(userSvc user.Servicer, postSvc post.Servicer) *ServerHandler {
-	return &ServerHandler{userSvc: userSvc, postSvc: postSvc}
+func NewServerHandler(
+	userSvc user.Servicer, postSvc post.Servicer, sessionSvc session.Servicer, tokenSvc token.Servicer,
+	enforcer *policy.Enforcer,
+) *ServerHandler {
+	return &ServerHandler{
+		userSvc:    userSvc,
+		postSvc:    postSvc,
+		sessionSvc: sessionSvc,
+		tokenSvc:   tokenSvc,
+		enforcer:   enforcer,
+	}
 }
 
-func success(w http.ResponseWriter, code int, body interface{}) {
+// callerIs reports whether the request was authenticated as the user with the given id.
+func callerIs(r *http.Request, id int64) bool {
+	caller, ok := auth.IdentityFromContext(r.Context())
+	return ok && caller.UserID == id
+}
+
+func success(w http.ResponseWriter, r *http.Request, code int, body interface{}) {
+	_, span := tracing.Start(r.Context(), "encode response", tracing.PhaseEncoding)
+	data, err := json.Marshal(body)
+	span.End()
+	if err != nil {
+		serverError(w, r, err, "unable to encode response")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(body)
+	_, _ = w.Write(append(data, '\n'))
 }
 
 func noContent(w http.ResponseWriter) {
@@ -36,19 +71,27 @@ func notFound(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
-func serverError(w http.ResponseWriter, err error, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
-	slog.Error(err.Error())
-	_ = json.NewEncoder(w).Encode(msg)
+func serverError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	slog.ErrorContext(r.Context(), msg, slog.String("error", err.Error()))
+	problem.Error(w, r, http.StatusInternalServerError, msg)
 }
 
-func unprocessableRequest(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusUnprocessableEntity)
+func badRequest(w http.ResponseWriter, r *http.Request, msg string) {
+	problem.Error(w, r, http.StatusBadRequest, msg)
 }
 
-func badRequest(w http.ResponseWriter, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	_ = json.NewEncoder(w).Encode(msg)
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	problem.Error(w, r, http.StatusUnauthorized, msg)
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request, msg string) {
+	problem.Error(w, r, http.StatusForbidden, msg)
+}
+
+// tooManyRequests writes a 429 problem, announcing retryAfter unless it is zero.
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, msg string) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	problem.Error(w, r, http.StatusTooManyRequests, msg)
 }
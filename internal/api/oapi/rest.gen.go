@@ -8,20 +8,69 @@ package oapi
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/oapi-codegen/runtime"
 )
 
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+	CookieAuthScopes = "cookieAuth.Scopes"
+)
+
+// Defines values for Role.
+const (
+	Admin  Role = "admin"
+	Editor Role = "editor"
+	Viewer Role = "viewer"
+)
+
+// Defines values for TokenScope.
+const (
+	PostsRead  TokenScope = "posts:read"
+	PostsWrite TokenScope = "posts:write"
+	UsersRead  TokenScope = "users:read"
+	UsersWrite TokenScope = "users:write"
+)
+
 // BadRequest defines model for BadRequest.
 type BadRequest = string
 
+// LoginInput defines model for LoginInput.
+type LoginInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// PasswordInput defines model for PasswordInput.
+type PasswordInput struct {
+	// CurrentPassword Current password, required when the user already has one
+	CurrentPassword *string `json:"current_password,omitempty"`
+
+	// NewPassword New password
+	NewPassword string `json:"new_password"`
+}
+
+// Permissions defines model for Permissions.
+type Permissions struct {
+	// Operations Operations the caller may perform, at least on resources they own
+	Operations  []string `json:"operations"`
+	Permissions []string `json:"permissions"`
+	Roles       []string `json:"roles"`
+
+	// Subject Subject the caller authenticated as
+	Subject *string `json:"subject,omitempty"`
+	UserId  *int64  `json:"user_id,omitempty"`
+}
+
 // Post defines model for Post.
 type Post struct {
 	// Content Post content
@@ -39,13 +88,94 @@ type PostInput struct {
 	Content string `json:"content"`
 
 	// Title Short headline of your post
-	Title  string `json:"title"`
-	UserId int64  `json:"user_id"`
+	Title string `json:"title"`
+
+	// UserId Author of the post, defaulting to the caller. Use the transfer operation to change the author of an existing post.
+	UserId *int64 `json:"user_id,omitempty"`
+}
+
+// PostTransfer defines model for PostTransfer.
+type PostTransfer struct {
+	// UserId User to become the author of the post
+	UserId int64 `json:"user_id"`
+}
+
+// Problem RFC 9457 problem details
+type Problem struct {
+	Detail   *string `json:"detail,omitempty"`
+	Instance *string `json:"instance,omitempty"`
+
+	// RequestId Identifier of the request, also returned in the X-Request-ID header
+	RequestId *string `json:"request_id,omitempty"`
+	Status    int     `json:"status"`
+	Title     string  `json:"title"`
+	Type      *string `json:"type,omitempty"`
+}
+
+// Quota Post quota of a user and its usage. Limits are absent when not enforced.
+type Quota struct {
+	// MaxPosts Posts that may be authored in total
+	MaxPosts *int `json:"max_posts,omitempty"`
+
+	// Posts Posts authored in total
+	Posts int `json:"posts"`
+
+	// Recent Posts created within the current window
+	Recent int `json:"recent"`
+
+	// ResetAt When the oldest post in the window leaves it
+	ResetAt *time.Time `json:"reset_at,omitempty"`
+
+	// WindowLimit Posts that may be created within the sliding window
+	WindowLimit *int `json:"window_limit,omitempty"`
+
+	// WindowSeconds Length of the sliding window
+	WindowSeconds *int64 `json:"window_seconds,omitempty"`
 }
 
 // ResourceNotFound defines model for ResourceNotFound.
 type ResourceNotFound = string
 
+// Role Access role, granting the permissions assigned to it by the access policy
+type Role string
+
+// Session defines model for Session.
+type Session struct {
+	// CsrfToken Token to send in the X-CSRF-Token header of cookie-authenticated writes
+	CsrfToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserId    int64     `json:"user_id"`
+}
+
+// Token defines model for Token.
+type Token struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Id         int64      `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name"`
+
+	// Prefix Leading characters of the token, to recognise it by
+	Prefix string       `json:"prefix"`
+	Scopes []TokenScope `json:"scopes"`
+
+	// Token The token secret, only returned when the token is created
+	Token *string `json:"token,omitempty"`
+}
+
+// TokenInput defines model for TokenInput.
+type TokenInput struct {
+	// ExpiresAt Optional expiry, tokens without one live until revoked
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Name Label to recognise the token by
+	Name   string       `json:"name"`
+	Scopes []TokenScope `json:"scopes"`
+}
+
+// TokenScope defines model for TokenScope.
+type TokenScope string
+
 // User defines model for User.
 type User struct {
 	// Email Users email address
@@ -54,6 +184,9 @@ type User struct {
 
 	// Name Users full name
 	Name string `json:"name"`
+
+	// Role Access role, granting the permissions assigned to it by the access policy
+	Role Role `json:"role"`
 }
 
 // UserInput defines model for UserInput.
@@ -63,8 +196,20 @@ type UserInput struct {
 
 	// Name Users full name
 	Name string `json:"name"`
+
+	// Role Access role, granting the permissions assigned to it by the access policy
+	Role *Role `json:"role,omitempty"`
 }
 
+// Forbidden RFC 9457 problem details
+type Forbidden = Problem
+
+// QuotaExceeded RFC 9457 problem details
+type QuotaExceeded = Problem
+
+// Unauthorized RFC 9457 problem details
+type Unauthorized = Problem
+
 // PostBody defines model for PostBody.
 type PostBody = PostInput
 
@@ -77,15 +222,30 @@ type CreatePostJSONRequestBody = PostInput
 // UpdatePostJSONRequestBody defines body for UpdatePost for application/json ContentType.
 type UpdatePostJSONRequestBody = PostInput
 
+// TransferPostJSONRequestBody defines body for TransferPost for application/json ContentType.
+type TransferPostJSONRequestBody = PostTransfer
+
+// CreateSessionJSONRequestBody defines body for CreateSession for application/json ContentType.
+type CreateSessionJSONRequestBody = LoginInput
+
 // CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
 type CreateUserJSONRequestBody = UserInput
 
 // UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
 type UpdateUserJSONRequestBody = UserInput
 
+// SetUserPasswordJSONRequestBody defines body for SetUserPassword for application/json ContentType.
+type SetUserPasswordJSONRequestBody = PasswordInput
+
+// CreateUserTokenJSONRequestBody defines body for CreateUserToken for application/json ContentType.
+type CreateUserTokenJSONRequestBody = TokenInput
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 
+	// (GET /me/permissions)
+	GetMyPermissions(w http.ResponseWriter, r *http.Request)
+
 	// (GET /posts)
 	ListPosts(w http.ResponseWriter, r *http.Request)
 
@@ -101,6 +261,15 @@ type ServerInterface interface {
 	// (PUT /posts/{id})
 	UpdatePost(w http.ResponseWriter, r *http.Request, id int64)
 
+	// (POST /posts/{id}/transfer)
+	TransferPost(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (POST /sessions)
+	CreateSession(w http.ResponseWriter, r *http.Request)
+
+	// (DELETE /sessions/current)
+	DeleteCurrentSession(w http.ResponseWriter, r *http.Request)
+
 	// (GET /users)
 	ListUsers(w http.ResponseWriter, r *http.Request)
 
@@ -115,6 +284,21 @@ type ServerInterface interface {
 
 	// (PUT /users/{id})
 	UpdateUser(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (PUT /users/{id}/password)
+	SetUserPassword(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (GET /users/{id}/quota)
+	GetUserQuota(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (GET /users/{id}/tokens)
+	ListUserTokens(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (POST /users/{id}/tokens)
+	CreateUserToken(w http.ResponseWriter, r *http.Request, id int64)
+
+	// (DELETE /users/{id}/tokens/{tokenId})
+	DeleteUserToken(w http.ResponseWriter, r *http.Request, id int64, tokenId int64)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -126,6 +310,28 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// GetMyPermissions operation middleware
+func (siw *ServerInterfaceWrapper) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMyPermissions(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListPosts operation middleware
 func (siw *ServerInterfaceWrapper) ListPosts(w http.ResponseWriter, r *http.Request) {
 
@@ -143,6 +349,14 @@ func (siw *ServerInterfaceWrapper) ListPosts(w http.ResponseWriter, r *http.Requ
 // CreatePost operation middleware
 func (siw *ServerInterfaceWrapper) CreatePost(w http.ResponseWriter, r *http.Request) {
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"posts:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.CreatePost(w, r)
 	}))
@@ -168,6 +382,14 @@ func (siw *ServerInterfaceWrapper) DeletePost(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"posts:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DeletePost(w, r, id)
 	}))
@@ -218,6 +440,14 @@ func (siw *ServerInterfaceWrapper) UpdatePost(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"posts:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.UpdatePost(w, r, id)
 	}))
@@ -229,6 +459,73 @@ func (siw *ServerInterfaceWrapper) UpdatePost(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
+// TransferPost operation middleware
+func (siw *ServerInterfaceWrapper) TransferPost(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"posts:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.TransferPost(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateSession operation middleware
+func (siw *ServerInterfaceWrapper) CreateSession(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteCurrentSession operation middleware
+func (siw *ServerInterfaceWrapper) DeleteCurrentSession(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteCurrentSession(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListUsers operation middleware
 func (siw *ServerInterfaceWrapper) ListUsers(w http.ResponseWriter, r *http.Request) {
 
@@ -246,6 +543,14 @@ func (siw *ServerInterfaceWrapper) ListUsers(w http.ResponseWriter, r *http.Requ
 // CreateUser operation middleware
 func (siw *ServerInterfaceWrapper) CreateUser(w http.ResponseWriter, r *http.Request) {
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"users:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.CreateUser(w, r)
 	}))
@@ -271,6 +576,14 @@ func (siw *ServerInterfaceWrapper) DeleteUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"users:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DeleteUser(w, r, id)
 	}))
@@ -321,6 +634,14 @@ func (siw *ServerInterfaceWrapper) UpdateUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{"users:write"})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.UpdateUser(w, r, id)
 	}))
@@ -332,6 +653,172 @@ func (siw *ServerInterfaceWrapper) UpdateUser(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
+// SetUserPassword operation middleware
+func (siw *ServerInterfaceWrapper) SetUserPassword(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SetUserPassword(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetUserQuota operation middleware
+func (siw *ServerInterfaceWrapper) GetUserQuota(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUserQuota(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListUserTokens operation middleware
+func (siw *ServerInterfaceWrapper) ListUserTokens(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListUserTokens(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateUserToken operation middleware
+func (siw *ServerInterfaceWrapper) CreateUserToken(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateUserToken(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteUserToken operation middleware
+func (siw *ServerInterfaceWrapper) DeleteUserToken(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "tokenId" -------------
+	var tokenId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "tokenId", r.PathValue("tokenId"), &tokenId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "tokenId", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, CookieAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteUserToken(w, r, id, tokenId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -452,16 +939,25 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	m.HandleFunc("GET "+options.BaseURL+"/me/permissions", wrapper.GetMyPermissions)
 	m.HandleFunc("GET "+options.BaseURL+"/posts", wrapper.ListPosts)
 	m.HandleFunc("POST "+options.BaseURL+"/posts", wrapper.CreatePost)
 	m.HandleFunc("DELETE "+options.BaseURL+"/posts/{id}", wrapper.DeletePost)
 	m.HandleFunc("GET "+options.BaseURL+"/posts/{id}", wrapper.GetPost)
 	m.HandleFunc("PUT "+options.BaseURL+"/posts/{id}", wrapper.UpdatePost)
+	m.HandleFunc("POST "+options.BaseURL+"/posts/{id}/transfer", wrapper.TransferPost)
+	m.HandleFunc("POST "+options.BaseURL+"/sessions", wrapper.CreateSession)
+	m.HandleFunc("DELETE "+options.BaseURL+"/sessions/current", wrapper.DeleteCurrentSession)
 	m.HandleFunc("GET "+options.BaseURL+"/users", wrapper.ListUsers)
 	m.HandleFunc("POST "+options.BaseURL+"/users", wrapper.CreateUser)
 	m.HandleFunc("DELETE "+options.BaseURL+"/users/{id}", wrapper.DeleteUser)
 	m.HandleFunc("GET "+options.BaseURL+"/users/{id}", wrapper.GetUser)
 	m.HandleFunc("PUT "+options.BaseURL+"/users/{id}", wrapper.UpdateUser)
+	m.HandleFunc("PUT "+options.BaseURL+"/users/{id}/password", wrapper.SetUserPassword)
+	m.HandleFunc("GET "+options.BaseURL+"/users/{id}/quota", wrapper.GetUserQuota)
+	m.HandleFunc("GET "+options.BaseURL+"/users/{id}/tokens", wrapper.ListUserTokens)
+	m.HandleFunc("POST "+options.BaseURL+"/users/{id}/tokens", wrapper.CreateUserToken)
+	m.HandleFunc("DELETE "+options.BaseURL+"/users/{id}/tokens/{tokenId}", wrapper.DeleteUserToken)
 
 	return m
 }
@@ -469,32 +965,70 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xZ3W8buRH/VwZsH/ckxfYlqZ7au8sVLtKDm4+nQ1DQ5Eg7OX6syaFlI1D/9mK4+rTW",
-	"cRzYSVrkbbUzHHJ+8/Ebrj4oE30XAwbOavpBJbwomPmnaAnri7NYf13Ls4mBMbA86q5zZDRTDOP3OQZ5",
-	"l02LXsvTnxPO1FT9abw1Pu6leSwGT0NXWC2Xy6ZuSAmtmnIquGzU24zpQTcUg7dvuGxWZqq3P2n7qkdA",
-	"flmc6eJYTdVpuNSOLBhHGBiommsUX3eopipzojBXy6aiJSu7FDtMvMJwxw2L2STqxA01reqwljYKr7Tv",
-	"nFh8GRN6oC4XDza6mCATg/bIjehnNIxcEmhLHWVDYQ7oiBvIaMFGQCrZRwuMvosJKBiyZEtgKAxOn8eE",
-	"gNybRvB6HjRoRxdFj+AtAwbyoC14kodLDKR9AxeFMoSYORULeIXJENeAQHFOexN7y6JEmWSnapI6wCtA",
-	"DSZ6H23sHbgomkfwi5jUhREolYQrXylAwi5hi8FiIsEbLqMrHWtGuBRPAXNGMOTcGiEELDArc9IMQQ4E",
-	"nU6kuaQRvLgy2DEWgTEwRGM0Gs1gSkdWs6yIAboUyWIQFEsNMpjiOi1+Q5zNyJAGixmTSH10cgwtAJEF",
-	"zCtcix+pRnl99RLDnFs1/XEymTTKU1i/OB7IG7KSHLOYvJZ0o8BPT3oz5ItX078cHR0fPzuaHD99/uPJ",
-	"s2dP1zZ76ZONRQqMc0xikokllW6m3Os2JoYWtXUUEOIMrmNJ0EniPkAG3nD+6IbvRwO+l4zp3w8OwF6l",
-	"/75Co1HbYltv+26zNp6/R8PrMu5bxvda/l7L96zl74X3+YX3CnMsyeBvkX+NJdh9Fl5LIUSGWZUPuCV8",
-	"f1i36DW5w6iIcoYqBG1twpz3ovE+tuGvXTl3ZEYm+o+D/IU6e9Aeb/NkVpyDqrDrxT9iG+BfIzirjtzX",
-	"iRsRJUF9vUVFdSiS26nrmwnFV8btDshEncIsHp7wTUsZ/nZ2CpIDSRvOsCBuQcO5Nn9gsMCtZlgkYswg",
-	"PRA4AoUfPPqYrsFo02KGRUumBZ0QFtShzLKoA5QuSofOrBOP4E2L8OrF6zez4mA7Skuz4vUhxMAcAybN",
-	"aGGWou9lNpria8fFkIsgADrIih9mlDJLt6V5AB0s/IHYiZxbBBMtSnvWjuZB1ve+iWhtsfKS9DdHBkOu",
-	"Mexjqf55+kY6SnJqqlrmLk/H48ViMfLEI7Rl/B8tpTR+efrzi99evxh5u9Og1esa5uqvnFM16hJT7jGf",
-	"jCajJ6IcOwy6IzVVx6PJaKIa1Wluax6PpXfXpzkODAS/IlfcNTjKLBj2+tVmqk6dWmn4lPlsJUmYO+nx",
-	"1erRZHKvSxAx+vwp169dEOrYIgfctlKdkr7uE3Lfo1dCPCHf8Ef0WM+z5Hhls3fLpn84gOTnhJorJCva",
-	"20eiF5/1ou1N9Po2n/Yuq+PNTXV5gOOTB729DkHTT3/1/DXHTu4Zu4/tuXMpHdi5NlmZj2REkFa2wCQV",
-	"1V9YY4KZJocW6u+6/VDEls0qnccfyC77yDnkgX75S32fpbgpWLokW7QbjmevuonnXkhObpmg+21XGJ48",
-	"GIYHg8VtMdyOFoN5fUelZwpzhxUNOL+GOoLSjDAdYPN35GFgJl8mV1c+fpsob7NZTX8/IOxAFwVBxsh9",
-	"gDe8/eT4+JmQqZrWZr0eV6b96LL/AajZce1gSjuYbd81ajXS3DhTZ2tju6sier1H6HCTx/o+NxC8Up34",
-	"RpNHGpmkxn14udcf4uW3K8nj83K9tezwsvz+XF7u/dkFR958Ei9XxWFeftuL7p21mw+6j8nLPXyH0FQY",
-	"/xd5eRWxTTp/Ji8PxrNX3cTzLl6uGH5FXq77Dxf9Jq8/jZcrZ9zJy8PATL5Mrn41Xv4UlP8feHmwInq9",
-	"R+hwk8f6G2sgeF+Rl+9OnvpHG6bLde5s7+zT8dhFo10bM0+fT55PlIRztXzwa802cUrPmkM3iR2t1T31",
-	"3fK/AQAA//8N3KuY7hwAAA==",
+	"H4sIAAAAAAAC/+w8a3PbuHZ/5QzbmdtOaUl+7CarfmluNmmdm93rtZ3Jncl4HIg8ErEmAQYALasZ9bd3",
+	"DgBSpATKsmN7s7n5ZJEAgfPGecGfo0QWpRQojI7GnyOFnyrU5q8y5WhfnEj7tKDfiRQGhaGfrCxznjDD",
+	"pRj+rqWgdzrJsGD0618VTqNx9C/D1eJDN6qHtOCxKCsTLZfL2G7IFabR2KgKl3H0TqN60A1pwf4N7Rtd",
+	"SqEdvq+lmvA0RbFl/1LJSY7Ff9wRcfeVgyJFnShe0nLROHrJ8hwVcA1CGmB5LueYgpFQoppKVYDJEGSJ",
+	"yu4fLePot0oa9uomQUwxfUpQzzMEVplMKsiYBoUsyQjWDLmCUmoDnwi0KI4yZCkqS9RTNGqx92JqUNFj",
+	"d8UzTKRINVTC8NxialeAORepnDtqaGBCmgzdFjGwiUZhYJ6hACnyBaSYo+FiZsc1ZJiXOopb6JpFidE4",
+	"4sLgDBVhRrImHCr8f5+WiC8VpigMZ7mGOSqEgmtN0EsFXFyznKcRfeWXop3+ytJTp5uOglNW5SYaR8du",
+	"OiQ5J4pwK+hxja42iosZCcxbOePCqcH4c1QqkibjdRwLxnP744YVZU4f/i4z8V9lNcl5MkhkEcVRwW7e",
+	"opiZLBofjEZxVHBRPx8G9iuZ1nOpLFlbn+6PDo42Zne08oMHp7XERfOFnPyOiaH1T/xgD0pJpRQKc9kG",
+	"Y40HbgbUM2KoYXBiRYJYaVTAcoUsXVhxlwJDxBU437LTrzhvdoniTWq0KPn8Ntp0dgrSBZUVJin0JlUa",
+	"E6I3ofx7M2ZRT5xJKtiiNkIxMAM5Mm1AClCoZaUStLMXIOciiiNusNAtbVtRyL9gSrEFPZddMHf/UMkc",
+	"7/iJrhx1Ni2PG2jjS/aANDNhBlNgOsRtkopLbhlNdGHG2ZUfj6J408x02eeg76Ift9kSZKnUIQlf2aou",
+	"VjQd6tG4pdNvpcICeKmrAlKZSwWaG2AFmpjma0wMmkoBS3nJdUL2CHNuYtCYQioBeaULmYLBorSGKuEp",
+	"TythoDKQs4lUCGjc0ggFmwkGLOefKjaAdwZQ8AJYCgWnH9coOCti+FTZU08bVaWAN6gSbiwtoMpzViTS",
+	"rUyTuOa0k12Sl4A3gAwSWRQylQ6BTxUzA/iZlmSVQeCqUuhx5SSzpcIMRYqKk6mEa5lXpWEG4ZowBdQa",
+	"IeF5XlMIASuYVjPODAgCCEqmODOVGgCdvqXBisgoDMgkYZgwA0lV8pQZ+kIKKJXkZOtj0JW1z5BUeckI",
+	"b5DTKU84gxQ1KhotZE5gMCIQTwG1p2tVDLqG44fRDia4R0QLdsOLqojGPx0cHB4+Oxgd/vj8h6Nnz36s",
+	"13Sj+5vCHEeGmxw3Re4sk8oAHfg5FwhyCgtZubP6ISRwsP34Obijjt6fAGva7KgRRytlq7ftU+O+0+q7",
+	"Ln/X5a26/GdUvC6oL1zQIKf2uHVuvHdjaTsjW8fwAN5ptM9GMaGnqFbRD81MMiZmbgJrlmUC8IbrJgog",
+	"4J9O9/s0/twjsKn0vZSigJWwnGAii3Usa+I9KnJbzZgPazbAPn39En46+uEZ+HAJUjSM59bd6SDu3nfj",
+	"DesYkWES0oD1joyL6ZCoEfLCuNCGiQQ7Jr5SfE/hFBXSSOArn98I0v3YxmRTjg2h/ewYWK4lKFIRgSkp",
+	"Po3+Y89HZHvHP4OLdkNbasNMpTvoHo0Ot56uzcRWOiLuc3R3R79HhD2AIWbbNEPPseSidFI8HyeJFLjR",
+	"UGk2wwG85QU9MYWdaJ34i2IqVYLpYEM0CnZzaSP48J4UbDBjg5JJrRSeH9KwvG3y9q3Mb9J46+qhFTeX",
+	"UJj0HtYaEoU2dphzk3lB8cGoz2j0rKnRXLLAqu/rYFTmKR0ihEAtgW5BCsquUQPvGIWUGdwzvAiqgfvw",
+	"Mice7ULqAFI65ymZ2gaphvQ/BAnvt9Qu27O5qTtUas3rX/35j0ejUQvPXSMvx7RaAELCfupD2l+leS0r",
+	"kXYTLfWoleCpHQ8Q9lSGjukXSYJaAxm5GGaKCXfkZehMnQsEgWnNZ8Il/riBycLOYO7bUuY8WRAhBNnw",
+	"DxFLCy7oOeVGqiiOrjnOUUUXAajO0G4RcDy1ml4aeYViE+hzek2waBQtm/fy7PT1nhtzNo9Ylkh5xXGv",
+	"Gz3PFTcYjKDxpuQKtZf33UT2C6Lu+tO4jW8HipA4nNdkWSOZU4U7wX4ffHdENY5yps1lpe8IkmAFBrMn",
+	"pcIpvwnpJ7MamWRMscSg0rWuWnrGJCkKEzkTXKOT344LqnR5+Y/F4dVvLHhIJrJcy+tsy61a3pzRN6GE",
+	"T59A17CCxkSR62tTx82x3qT93CTe2PJbT1IrW5aiDf0alOK2xPTKWV9mtiM46wk7+sFysJMWsQNbWxst",
+	"KwNSIOT8Gn1iXeG1vLK43E1A1qSATTDvsnpFsTWOCz7LTL4g+KQy65nPbiix/9AyUXBx7L7aXxeQNeZ5",
+	"vvndejnkVh5/bgwwWRU9VshSH/jrsbV49RlTD7kHNxQyztbB7c/Jb8YGGuwgsDRVqHUUP2Te/hGSRmFJ",
+	"cphMqzwHz4EVFm9kJuC3AZxYRO6OhPKn8DZxsSf1NkWuyxB2sZBYrAqLXw33/kSk7lB5k7yk/5hUipvF",
+	"GS3j6DpBplBRKmH19LqW1Tfvz6P1Stub9+fgvarJAhhc4QIsgXW2CuQ0qmtUf9Hw5v3fzmDKyUuTChh5",
+	"Z9qaWO+EOTP3b87EYwof6VD7+O8DOAlNdMGP9bGdU2f3smYGcq6Ng4kKqKvsxl801GjX9agChRnDx5W5",
+	"+RjXT9ao0OPK4Hy0cdjHltH5OKgLoURfR7OVwc+MKYmPzoOrKbteobXuYxMFTBaQy9mMvAEuBvBOaDZt",
+	"gmUNRaWNi5it59jjNhJUnFZ3O9d6N460220FIiv533Dh6qhcTGXoZOcaXpwcAxcGyTtxpyAwmLDkygHB",
+	"jHdIgRJ61scWewUWUi0goSK2hnnGk8wybc5LpJoqMgFV6cpdhikzAHIiTl+dnU+rHFai7lwhDwQtMENB",
+	"HMUUpkoWbiyVSVXY9CEKXZECUc7qxcnx3pQrbSBFklTLvyvEsg4REpkiSSrL+UwULo40mR2qV7SiQwTN",
+	"eYJCW4X01PzlmJSiUrnntR4Ph/P5fFBwM8C0Gv4fI+M9fHv88tWvZ68GRdpKRERn1kpYfAlOCjJQuUgi",
+	"Gg1Gg32aLEsUrOTRODocjAYjW7g1mVXXYYHDtUrfDAOuzM/2aeKKieByQUSG1re1s1nX6bxgyU7VctGu",
+	"V7YLa8dpNI7+G80vi5NO5a3T+nEwGj1cl0trm/6GjzZtlnF0NNrvW7cBdNjpWWibyWj8oWsgP1ws488d",
+	"xf5wsSRLy2aaLHCtaBe0yrBJjAQ59BqNVRFmLRcxw81fp/Fbrs2JH/ki4u7k8tFObXmlZwtgtOHzbbDg",
+	"1Lr9eg2f5XJFH3oTXficUaCBwFpDIonPx3Yp4YZP3NCqrWrRz+FW59WwabtabtBx/0FbsUKkcVUnH/tY",
+	"uXw4xWj1sQR2tu4U1WWoNIHKd8f4lhg6k6eM55iCfW6aoe6hN/TR4e0frdKv9MXBT7d/0W3M2q6f3dBg",
+	"u7J6YWw0dfiZp0snlDkaDNlUek92FLhI+TVPK5aHRdVNbUS1I21HPdlft236lOQfHT2YFG6k+vq0YJXs",
+	"e3BWxrdZWs3FLHfVHnK5eFOeCJ1sYe6NnsZWeAp9FTzatN6NNbFsWwuNBP9U+UavDoFXhYTDw2feUyW/",
+	"ZuWn2lix203abji8PUl5EUc+eFyDqUztwXKb2rp5j3DCjB6r2TfAvMoi8d2OPNSRMDTtgvMOou/qSX+A",
+	"6AedqrpcrqmjEJXOeOlKjBZMI5s+YNLYAfydErjtAjlFBmBLI1wbxYx0LYw1UfxCgw1dqvcNa9OD6UK9",
+	"TU/v+x9jvWviqKf29qgx1rIZ5ky3VeSfxhZ8PU6lDwZd03BQNd/KGZ1JLvvgc5gUo/tuZJca8cusSijt",
+	"pgnmclsYw/8YU1rddbD957YCossnYZJJ35PhF6SMUr2P2JZlCsVlZ02S6TFUvdVsv5OiP1xIV+MVkL21",
+	"LF73hsYZmr2Xlgf96T/itiW6Y1bwfkVTIFveW4kPftpCjQe/ivEiSaRt5tOQy+QKU2BTgwoUli7ZmRMv",
+	"bdxZKdRdqt3xXgttQJU5X9W77X7Ksi9V45/00DeWbAsDrdbSpnVjhWdmc+KndaNBPdLkY0NBor810Vaf",
+	"28LFWny+LGJct3m7prVsovwOaS03P5TWeudHHj+tRTu101r0fN+0lsOnLUv0Zqe0lp0YNp++Ke/OQUdz",
+	"ue8xbaAj3yZpLBm/p7W6PtB2X6Jd1r7Fl/Bi1ejcPRNUQaH7ud0JervFsYz+9hNUFs2dA8u7s3LXBBVN",
+	"vz1BFebe6Gm0/g9LUAV4tGmHv4EEVVBt3bxHOCtGj3U5PMC8bz9B9dh2pHskDNsXZf90on+GRtOB7W6g",
+	"uHp1jdCqF34AL2ncV/hXt1LqiR4y3ekOlwJbCS2LNaWuVndduKJsSevCMgVkxDeTKVnNsr6Glc0Y+MyZ",
+	"45PVpeRHSXh1rmjvFAiHqk010TqK+FSeW7P7hodmpWDtDjlFkVwkUinfIvbdYtzahRA2Ep/qiydBF+Qs",
+	"o38Jkck5FFVS3xno/P+J+lIKXdpPpNBVgemGGnivxF1yecRDxm0QoKMdcBdmvrso97PTa3LjGu96BYfi",
+	"eF1fvAh17LVMeGOK7RC4pj/ScJ3JecCo1jmCcwfCUyQK7FbtTIF9cd9UgafdVxF57hqhfNUORDC/8gsX",
+	"Rvce1bB+SYEErntNwabrbGbdEXUATuIgYYLUeEL3tQWb+etaPZI+2JLZOfcXZB7DKWjddnji1LhXlsC/",
+	"NLLk/p4Y+va9Cif6w8/27/H29NSpvS/Tq6lbclQr9bnNt3WSV9/M+XY56/B8WNZ+xbY/7gHGFyuD0Bwd",
+	"BGHxkvrFXpIluLquabXqBx8Ph7lMWJ5JbcbPR89HUYvWwYskK+AqV6cIXh9ezfKNtXGgMsUFNHXnjf7/",
+	"5cXy/wcApYXdu55QAAA=",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/policy"
+)
+
+func (s *ServerHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	roles := policy.RolesFromContext(r.Context())
+
+	body := &oapi.Permissions{
+		Roles:       roles,
+		Permissions: s.enforcer.Permissions(roles),
+		Operations:  s.enforcer.AllowedOperations(roles),
+	}
+	if body.Permissions == nil {
+		body.Permissions = []string{}
+	}
+
+	if caller, ok := auth.IdentityFromContext(r.Context()); ok {
+		body.Subject = &caller.Subject
+		if caller.UserID > 0 {
+			body.UserId = &caller.UserID
+		}
+	}
+
+	success(w, r, http.StatusOK, body)
+}
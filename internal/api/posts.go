@@ -1,88 +1,97 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/policy"
 	"github.com/jqdurham/rest-sample/internal/post"
 )
 
-func (s *ServerHandler) ListPosts(w http.ResponseWriter, _ *http.Request) {
-	posts := s.postSvc.ListPosts()
+func (s *ServerHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
+	posts := s.postSvc.ListPosts(r.Context())
 
 	body := make([]*oapi.Post, len(posts))
 	for i, p := range posts {
 		body[i] = toAPIPost(&p)
 	}
 
-	success(w, http.StatusOK, body)
+	success(w, r, http.StatusOK, body)
 }
 
 func (s *ServerHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	var postInput oapi.PostInput
-	if err := json.NewDecoder(r.Body).Decode(&postInput); err != nil {
-		unprocessableRequest(w)
+	if !decodeJSON(w, r, &postInput) {
 		return
 	}
 
-	body, err := s.postSvc.CreatePost(toPost(postInput))
+	body, err := s.postSvc.CreatePost(r.Context(), postActor(r), toPost(postInput))
 	if err != nil {
 		var vf *post.InvalidError
 		if errors.As(err, &vf) {
-			badRequest(w, vf.Error())
+			badRequest(w, r, vf.Error())
 			return
 		}
-		serverError(w, err, "unable to create post")
+		var ff *post.ForbiddenError
+		if errors.As(err, &ff) {
+			forbidden(w, r, ff.Error())
+			return
+		}
+		var qe *post.QuotaError
+		if errors.As(err, &qe) {
+			tooManyRequests(w, r, qe.RetryAfter(), qe.Error())
+			return
+		}
+		serverError(w, r, err, "unable to create post")
 		return
 	}
 
-	success(w, http.StatusCreated, toAPIPost(body))
+	success(w, r, http.StatusCreated, toAPIPost(body))
 }
 
-func (s *ServerHandler) DeletePost(w http.ResponseWriter, _ *http.Request, id int64) {
-	if _, err := s.postSvc.GetPost(id); err != nil {
+func (s *ServerHandler) DeletePost(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := s.postSvc.DeletePost(r.Context(), postActor(r), id); err != nil {
 		var nf *post.NotFoundError
 		if errors.As(err, &nf) {
 			notFound(w)
 			return
 		}
-		serverError(w, err, "unable to locate post")
-		return
-	}
-
-	if err := s.postSvc.DeletePost(id); err != nil {
-		serverError(w, err, "unable to delete post")
+		var ff *post.ForbiddenError
+		if errors.As(err, &ff) {
+			forbidden(w, r, ff.Error())
+			return
+		}
+		serverError(w, r, err, "unable to delete post")
 		return
 	}
 
 	noContent(w)
 }
 
-func (s *ServerHandler) GetPost(w http.ResponseWriter, _ *http.Request, id int64) {
-	pst, err := s.postSvc.GetPost(id)
+func (s *ServerHandler) GetPost(w http.ResponseWriter, r *http.Request, id int64) {
+	pst, err := s.postSvc.GetPost(r.Context(), id)
 	if err != nil {
 		var nf *post.NotFoundError
 		if errors.As(err, &nf) {
 			notFound(w)
 			return
 		}
-		serverError(w, err, "unable to locate post")
+		serverError(w, r, err, "unable to locate post")
 		return
 	}
 
-	success(w, http.StatusOK, toAPIPost(pst))
+	success(w, r, http.StatusOK, toAPIPost(pst))
 }
 
 func (s *ServerHandler) UpdatePost(w http.ResponseWriter, r *http.Request, id int64) {
 	var postInput oapi.PostInput
-	if err := json.NewDecoder(r.Body).Decode(&postInput); err != nil {
-		unprocessableRequest(w)
+	if !decodeJSON(w, r, &postInput) {
 		return
 	}
 
-	pst, err := s.postSvc.UpdatePost(id, toPost(postInput))
+	pst, err := s.postSvc.UpdatePost(r.Context(), postActor(r), id, toPost(postInput))
 	if err != nil {
 		var nf *post.NotFoundError
 		if errors.As(err, &nf) {
@@ -91,22 +100,75 @@ func (s *ServerHandler) UpdatePost(w http.ResponseWriter, r *http.Request, id in
 		}
 		var vf *post.InvalidError
 		if errors.As(err, &vf) {
-			badRequest(w, vf.Error())
+			badRequest(w, r, vf.Error())
 			return
 		}
-		serverError(w, err, "unable to update post")
+		var ff *post.ForbiddenError
+		if errors.As(err, &ff) {
+			forbidden(w, r, ff.Error())
+			return
+		}
+		serverError(w, r, err, "unable to update post")
 		return
 	}
 
-	success(w, http.StatusOK, toAPIPost(pst))
+	success(w, r, http.StatusOK, toAPIPost(pst))
+}
+
+func (s *ServerHandler) TransferPost(w http.ResponseWriter, r *http.Request, id int64) {
+	var transfer oapi.PostTransfer
+	if !decodeJSON(w, r, &transfer) {
+		return
+	}
+
+	pst, err := s.postSvc.TransferPost(r.Context(), postActor(r), id, transfer.UserId)
+	if err != nil {
+		var nf *post.NotFoundError
+		if errors.As(err, &nf) {
+			notFound(w)
+			return
+		}
+		var vf *post.InvalidError
+		if errors.As(err, &vf) {
+			badRequest(w, r, vf.Error())
+			return
+		}
+		var ff *post.ForbiddenError
+		if errors.As(err, &ff) {
+			forbidden(w, r, ff.Error())
+			return
+		}
+		var qe *post.QuotaError
+		if errors.As(err, &qe) {
+			tooManyRequests(w, r, qe.RetryAfter(), qe.Error())
+			return
+		}
+		serverError(w, r, err, "unable to transfer post")
+		return
+	}
+
+	success(w, r, http.StatusOK, toAPIPost(pst))
+}
+
+// postActor describes the caller to the post service. Callers the policy granted the operation without the ":own"
+// suffix act on posts of other users.
+func postActor(r *http.Request) post.Actor {
+	actor := post.Actor{Admin: policy.AnyResource(r.Context())}
+	if caller, ok := auth.IdentityFromContext(r.Context()); ok {
+		actor.UserID = caller.UserID
+	}
+	return actor
 }
 
 func toPost(input oapi.PostInput) *post.Post {
-	return &post.Post{
+	pst := &post.Post{
 		Content: input.Content,
 		Title:   input.Title,
-		UserID:  input.UserId,
 	}
+	if input.UserId != nil {
+		pst.UserID = *input.UserId
+	}
+	return pst
 }
 
 func toAPIPost(pst *post.Post) *oapi.Post {
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/policy"
+	"github.com/jqdurham/rest-sample/internal/post"
+)
+
+func TestPostActor(t *testing.T) {
+	t.Parallel()
+	// Editors may write their own posts, moderators any post.
+	file := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(file, []byte(`{
+		"roles": {"editor": ["posts:write:own"], "moderator": ["posts:write"]},
+		"operations": {"UpdatePost": ["posts:write"]}
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pol, err := policy.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swagger, err := oapi.GetSwagger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	swagger.Servers = nil
+	resolveOperation, err := operation.Middleware(swagger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		method string
+		path   string
+		want   post.Actor
+	}{
+		{name: "Own permission acts as author", role: "editor", method: http.MethodPut, path: "/posts/1", want: post.Actor{UserID: 7}},
+		{name: "Permission acts on any post", role: "moderator", method: http.MethodPut, path: "/posts/1", want: post.Actor{UserID: 7, Admin: true}},
+		{name: "Undecided operation acts as author", role: "moderator", method: http.MethodGet, path: "/", want: post.Actor{UserID: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got post.Actor
+			h := policy.Middleware(policy.NewEnforcer(pol), nil)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = postActor(r)
+			}))
+			h = resolveOperation(h)
+
+			id := auth.NewIdentity("test", "7")
+			id.UserID = 7
+			id.Roles = []string{tt.role}
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			h.ServeHTTP(httptest.NewRecorder(), r.WithContext(auth.WithIdentity(r.Context(), id)))
+
+			if got != tt.want {
+				t.Errorf("postActor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
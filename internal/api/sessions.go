@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/session"
+	"github.com/jqdurham/rest-sample/internal/user"
+)
+
+func (s *ServerHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var loginInput oapi.LoginInput
+	if !decodeJSON(w, r, &loginInput) {
+		return
+	}
+
+	usr, err := s.userSvc.Authenticate(r.Context(), loginInput.Email, loginInput.Password)
+	if err != nil {
+		var lf *user.LockedError
+		if errors.As(err, &lf) {
+			tooManyRequests(w, r, time.Until(lf.Until()), lf.Error())
+			return
+		}
+		var af *user.AuthenticationError
+		if errors.As(err, &af) {
+			unauthorized(w, r, af.Error())
+			return
+		}
+		serverError(w, r, err, "unable to log in")
+		return
+	}
+
+	sess, err := s.sessionSvc.CreateSession(usr.ID)
+	if err != nil {
+		serverError(w, r, err, "unable to create session")
+		return
+	}
+
+	setSessionCookies(w, sess.Token, sess.CSRFToken, sess.ExpiresAt)
+
+	success(w, r, http.StatusCreated, toAPISession(sess))
+}
+
+func (s *ServerHandler) DeleteCurrentSession(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(auth.SessionCookie)
+	if err != nil {
+		unauthorized(w, r, "no session")
+		return
+	}
+
+	if err := s.sessionSvc.DeleteSession(cookie.Value); err != nil {
+		var nf *session.NotFoundError
+		if !errors.As(err, &nf) {
+			serverError(w, r, err, "unable to delete session")
+			return
+		}
+	}
+
+	setSessionCookies(w, "", "", time.Unix(0, 0))
+
+	noContent(w)
+}
+
+// setSessionCookies writes the session and CSRF cookies; an expiry in the past clears them.
+func setSessionCookies(w http.ResponseWriter, token, csrf string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.CSRFCookie,
+		Value:    csrf,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func toAPISession(sess *session.Session) *oapi.Session {
+	return &oapi.Session{
+		UserId:    sess.UserID,
+		CsrfToken: sess.CSRFToken,
+		ExpiresAt: sess.ExpiresAt,
+	}
+}
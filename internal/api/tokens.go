@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/token"
+	"github.com/jqdurham/rest-sample/internal/user"
+)
+
+func (s *ServerHandler) ListUserTokens(w http.ResponseWriter, r *http.Request, id int64) {
+	if !s.canManageTokens(r, id) {
+		forbidden(w, r, "users may only manage their own tokens, without using a token")
+		return
+	}
+
+	tokens := s.tokenSvc.ListTokens(id)
+
+	body := make([]*oapi.Token, len(tokens))
+	for i, t := range tokens {
+		body[i] = toAPIToken(&t)
+	}
+
+	success(w, r, http.StatusOK, body)
+}
+
+func (s *ServerHandler) CreateUserToken(w http.ResponseWriter, r *http.Request, id int64) {
+	if !s.canManageTokens(r, id) {
+		forbidden(w, r, "users may only manage their own tokens, without using a token")
+		return
+	}
+
+	var tokenInput oapi.TokenInput
+	if !decodeJSON(w, r, &tokenInput) {
+		return
+	}
+
+	if _, err := s.userSvc.GetUser(r.Context(), id); err != nil {
+		var nf *user.NotFoundError
+		if errors.As(err, &nf) {
+			notFound(w)
+			return
+		}
+		serverError(w, r, err, "unable to locate user")
+		return
+	}
+
+	scopes := make([]string, len(tokenInput.Scopes))
+	for i, sc := range tokenInput.Scopes {
+		scopes[i] = string(sc)
+	}
+
+	tkn, secret, err := s.tokenSvc.CreateToken(id, tokenInput.Name, scopes, tokenInput.ExpiresAt)
+	if err != nil {
+		var vf *token.InvalidError
+		if errors.As(err, &vf) {
+			badRequest(w, r, vf.Error())
+			return
+		}
+		serverError(w, r, err, "unable to create token")
+		return
+	}
+
+	body := toAPIToken(tkn)
+	body.Token = &secret
+
+	success(w, r, http.StatusCreated, body)
+}
+
+func (s *ServerHandler) DeleteUserToken(w http.ResponseWriter, r *http.Request, id int64, tokenID int64) {
+	if !s.canManageTokens(r, id) {
+		forbidden(w, r, "users may only manage their own tokens, without using a token")
+		return
+	}
+
+	if err := s.tokenSvc.DeleteToken(id, tokenID); err != nil {
+		var nf *token.NotFoundError
+		if errors.As(err, &nf) {
+			notFound(w)
+			return
+		}
+		serverError(w, r, err, "unable to delete token")
+		return
+	}
+
+	noContent(w)
+}
+
+// canManageTokens only lets users manage their own tokens, and never through a personal access token, so
+// that a leaked token cannot be used to mint tokens with broader scopes.
+func (s *ServerHandler) canManageTokens(r *http.Request, id int64) bool {
+	caller, ok := auth.IdentityFromContext(r.Context())
+	return ok && caller.UserID == id && caller.Method != auth.MethodToken
+}
+
+func toAPIToken(tkn *token.Token) *oapi.Token {
+	scopes := make([]oapi.TokenScope, len(tkn.Scopes))
+	for i, sc := range tkn.Scopes {
+		scopes[i] = oapi.TokenScope(sc)
+	}
+
+	return &oapi.Token{
+		Id:         tkn.ID,
+		Name:       tkn.Name,
+		Prefix:     tkn.DisplayPrefix,
+		Scopes:     scopes,
+		CreatedAt:  tkn.CreatedAt,
+		ExpiresAt:  tkn.ExpiresAt,
+		LastUsedAt: tkn.LastUsedAt,
+	}
+}
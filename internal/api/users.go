@@ -1,88 +1,103 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/jqdurham/rest-sample/internal/api/oapi"
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/post"
 	"github.com/jqdurham/rest-sample/internal/user"
 )
 
-func (s *ServerHandler) ListUsers(w http.ResponseWriter, _ *http.Request) {
-	users := s.userSvc.ListUsers()
+func (s *ServerHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users := s.userSvc.ListUsers(r.Context())
 
 	body := make([]*oapi.User, len(users))
 	for i, u := range users {
 		body[i] = toAPIUser(&u)
 	}
 
-	success(w, http.StatusOK, body)
+	success(w, r, http.StatusOK, body)
 }
 
 func (s *ServerHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var userInput oapi.UserInput
-	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		unprocessableRequest(w)
+	if !decodeJSON(w, r, &userInput) {
 		return
 	}
 
-	usr, err := s.userSvc.CreateUser(toUser(userInput))
+	usr, err := s.userSvc.CreateUser(r.Context(), toUser(userInput))
 	if err != nil {
 		var vf *user.InvalidError
 		if errors.As(err, &vf) {
-			badRequest(w, vf.Error())
+			badRequest(w, r, vf.Error())
 			return
 		}
-		serverError(w, err, "unable to create user")
+		serverError(w, r, err, "unable to create user")
 		return
 	}
 
-	success(w, http.StatusCreated, toAPIUser(usr))
+	success(w, r, http.StatusCreated, toAPIUser(usr))
 }
 
-func (s *ServerHandler) DeleteUser(w http.ResponseWriter, _ *http.Request, id int64) {
-	if _, err := s.userSvc.GetUser(id); err != nil {
+func (s *ServerHandler) DeleteUser(w http.ResponseWriter, r *http.Request, id int64) {
+	if _, err := s.userSvc.GetUser(r.Context(), id); err != nil {
 		var nf *user.NotFoundError
 		if errors.As(err, &nf) {
 			notFound(w)
 			return
 		}
-		serverError(w, err, "unable to locate user")
+		serverError(w, r, err, "unable to locate user")
 		return
 	}
 
-	if err := s.userSvc.DeleteUser(id); err != nil {
-		serverError(w, err, "unable to delete user")
+	if err := s.userSvc.DeleteUser(r.Context(), id); err != nil {
+		serverError(w, r, err, "unable to delete user")
 		return
 	}
+	s.sessionSvc.DeleteUserSessions(id)
+	s.tokenSvc.DeleteUserTokens(id)
 
 	noContent(w)
 }
 
-func (s *ServerHandler) GetUser(w http.ResponseWriter, _ *http.Request, id int64) {
-	usr, err := s.userSvc.GetUser(id)
+func (s *ServerHandler) GetUser(w http.ResponseWriter, r *http.Request, id int64) {
+	usr, err := s.userSvc.GetUser(r.Context(), id)
 	if err != nil {
 		var nf *user.NotFoundError
 		if errors.As(err, &nf) {
 			notFound(w)
 			return
 		}
-		serverError(w, err, "unable to locate user")
+		serverError(w, r, err, "unable to locate user")
 		return
 	}
 
-	success(w, http.StatusOK, toAPIUser(usr))
+	success(w, r, http.StatusOK, toAPIUser(usr))
+}
+
+func (s *ServerHandler) GetUserQuota(w http.ResponseWriter, r *http.Request, id int64) {
+	if _, err := s.userSvc.GetUser(r.Context(), id); err != nil {
+		var nf *user.NotFoundError
+		if errors.As(err, &nf) {
+			notFound(w)
+			return
+		}
+		serverError(w, r, err, "unable to locate user")
+		return
+	}
+
+	success(w, r, http.StatusOK, toAPIQuota(s.postSvc.GetUsage(r.Context(), id)))
 }
 
 func (s *ServerHandler) UpdateUser(w http.ResponseWriter, r *http.Request, id int64) {
 	var userInput oapi.UserInput
-	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		unprocessableRequest(w)
+	if !decodeJSON(w, r, &userInput) {
 		return
 	}
 
-	usr, err := s.userSvc.UpdateUser(id, toUser(userInput))
+	usr, err := s.userSvc.UpdateUser(r.Context(), id, toUser(userInput))
 	if err != nil {
 		var nf *user.NotFoundError
 		if errors.As(err, &nf) {
@@ -91,21 +106,68 @@ func (s *ServerHandler) UpdateUser(w http.ResponseWriter, r *http.Request, id in
 		}
 		var vf *user.InvalidError
 		if errors.As(err, &vf) {
-			badRequest(w, vf.Error())
+			badRequest(w, r, vf.Error())
+			return
+		}
+		serverError(w, r, err, "unable to update user")
+		return
+	}
+
+	success(w, r, http.StatusOK, toAPIUser(usr))
+}
+
+func (s *ServerHandler) SetUserPassword(w http.ResponseWriter, r *http.Request, id int64) {
+	// A token must not set a password, which would let it log in to a session without the token's scopes.
+	if caller, ok := auth.IdentityFromContext(r.Context()); !ok || caller.UserID != id || caller.Method == auth.MethodToken {
+		forbidden(w, r, "users may only change their own password, without using a token")
+		return
+	}
+
+	var input oapi.PasswordInput
+	if !decodeJSON(w, r, &input) {
+		return
+	}
+
+	var current string
+	if input.CurrentPassword != nil {
+		current = *input.CurrentPassword
+	}
+
+	if err := s.userSvc.SetPassword(r.Context(), id, current, input.NewPassword); err != nil {
+		var nf *user.NotFoundError
+		if errors.As(err, &nf) {
+			notFound(w)
+			return
+		}
+		var vf *user.InvalidError
+		if errors.As(err, &vf) {
+			badRequest(w, r, vf.Error())
 			return
 		}
-		serverError(w, err, "unable to update user")
+		var af *user.AuthenticationError
+		if errors.As(err, &af) {
+			badRequest(w, r, af.Error())
+			return
+		}
+		serverError(w, r, err, "unable to set password")
 		return
 	}
 
-	success(w, http.StatusOK, toAPIUser(usr))
+	// Sessions established with the old password must not outlive it.
+	s.sessionSvc.DeleteUserSessions(id)
+
+	noContent(w)
 }
 
 func toUser(input oapi.UserInput) *user.User {
-	return &user.User{
+	usr := &user.User{
 		Name:  input.Name,
 		Email: input.Email,
 	}
+	if input.Role != nil {
+		usr.Role = string(*input.Role)
+	}
+	return usr
 }
 
 func toAPIUser(usr *user.User) *oapi.User {
@@ -113,5 +175,23 @@ func toAPIUser(usr *user.User) *oapi.User {
 		Id:    usr.ID,
 		Name:  usr.Name,
 		Email: usr.Email,
+		Role:  oapi.Role(usr.Role),
+	}
+}
+
+func toAPIQuota(usage post.Usage) *oapi.Quota {
+	out := &oapi.Quota{
+		Recent:  usage.Recent,
+		Posts:   usage.Posts,
+		ResetAt: usage.ResetAt,
+	}
+	if usage.WindowLimit > 0 && usage.Window > 0 {
+		window := int64(usage.Window.Seconds())
+		out.WindowLimit = &usage.WindowLimit
+		out.WindowSeconds = &window
+	}
+	if usage.MaxPosts > 0 {
+		out.MaxPosts = &usage.MaxPosts
 	}
+	return out
 }
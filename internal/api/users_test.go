@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/session"
+	"github.com/jqdurham/rest-sample/internal/user"
+)
+
+func TestServerHandler_SetUserPassword(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		method   string
+		userID   int64
+		wantCode int
+	}{
+		{name: "Sets own password in a session", method: auth.MethodSession, userID: 1, wantCode: http.StatusNoContent},
+		{name: "Refuses personal access token", method: auth.MethodToken, userID: 1, wantCode: http.StatusForbidden},
+		{name: "Refuses other user", method: auth.MethodSession, userID: 2, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			userSvc := user.NewService()
+			usr, err := userSvc.CreateUser(context.Background(), &user.User{Name: "John Q. Public", Email: "john@public.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewServerHandler(userSvc, nil, session.NewService(time.Hour, time.Hour), nil, nil)
+
+			id := auth.NewIdentity("test", "1")
+			id.UserID = tt.userID
+			id.Method = tt.method
+			r := httptest.NewRequest(http.MethodPut, "/users/1/password", strings.NewReader(`{"new_password":"correct horse"}`))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			s.SetUserPassword(w, r.WithContext(auth.WithIdentity(r.Context(), id)), usr.ID)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("SetUserPassword() status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "net/http"
+
+// CertificateAuthenticator authenticates requests over connections presenting a client certificate verified
+// against the client CA bundle. The subject common name becomes the identity subject, resolving numeric names to
+// local users, and the organizational units become its roles.
+type CertificateAuthenticator struct{}
+
+func NewCertificateAuthenticator() *CertificateAuthenticator {
+	return &CertificateAuthenticator{}
+}
+
+func (a *CertificateAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil, &InvalidTokenError{message: "client certificate has no common name"}
+	}
+
+	id := NewIdentity(cert.Issuer.String(), cert.Subject.CommonName)
+	id.Method = MethodCertificate
+	id.Roles = cert.Subject.OrganizationalUnit
+
+	return id, nil
+}
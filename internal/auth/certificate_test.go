@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestCertificateAuthenticator(t *testing.T) {
+	verified := func(subject pkix.Name) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: subject, Issuer: pkix.Name{CommonName: "test ca"}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	if _, err := NewCertificateAuthenticator().Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("plain request: error = %v, want ErrNoCredentials", err)
+	}
+
+	r.TLS = &tls.ConnectionState{}
+	if _, err := NewCertificateAuthenticator().Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unverified connection: error = %v, want ErrNoCredentials", err)
+	}
+
+	r.TLS = verified(pkix.Name{CommonName: "42", OrganizationalUnit: []string{"admin"}})
+	id, err := NewCertificateAuthenticator().Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.UserID != 42 || id.Issuer != "CN=test ca" || id.Method != MethodCertificate || !slices.Equal(id.Roles, []string{"admin"}) {
+		t.Errorf("identity = %+v", id)
+	}
+
+	r.TLS = verified(pkix.Name{OrganizationalUnit: []string{"admin"}})
+	if _, err := NewCertificateAuthenticator().Authenticate(r); err == nil {
+		t.Error("certificate without common name accepted")
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "errors"
+
+// ErrNoCredentials is returned when a request does not carry credentials for an authenticator.
+var ErrNoCredentials = errors.New("no credentials provided")
+
+type InvalidTokenError struct {
+	message string
+}
+
+func (e InvalidTokenError) Error() string {
+	return e.message
+}
+
+type KeyNotFoundError struct {
+	kid string
+}
+
+func (e KeyNotFoundError) Error() string {
+	if e.kid == "" {
+		return "no matching signing key found"
+	}
+	return "signing key " + e.kid + " not found"
+}
+
+type CSRFError struct{}
+
+func (e CSRFError) Error() string {
+	return "missing or invalid CSRF token"
+}
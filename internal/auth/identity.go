@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strconv"
+)
+
+type ctxKey struct{}
+
+// Authentication methods an Identity may have been established with.
+const (
+	MethodBearer  = "bearer"
+	MethodSession = "session"
+	MethodToken   = "token"
+	// MethodCertificate identifies callers by a verified TLS client certificate.
+	MethodCertificate = "certificate"
+)
+
+// Identity describes the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Issuer  string
+	UserID  int64
+	Method  string
+	// Scopes restrict what a caller authenticated with a personal access token may do.
+	Scopes []string
+	// Roles are asserted by the credentials themselves, such as the roles claim of a JWT.
+	Roles []string
+}
+
+// NewIdentity creates an identity for the subject, resolving subjects that are numeric to a local user ID.
+func NewIdentity(issuer, subject string) *Identity {
+	id := &Identity{Subject: subject, Issuer: issuer}
+	if userID, err := strconv.ParseInt(subject, 10, 64); err == nil && userID > 0 {
+		id.UserID = userID
+	}
+	return id
+}
+
+// WithIdentity returns a copy of ctx carrying the caller identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// IdentityFromContext returns the caller identity, if the request was authenticated.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// HasScopes reports whether the identity may perform an operation requiring the scopes. Only personal
+// access tokens are restricted by scopes.
+func (id *Identity) HasScopes(required []string) bool {
+	if id.Method != MethodToken {
+		return true
+	}
+	for _, s := range required {
+		if !slices.Contains(id.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
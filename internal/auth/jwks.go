@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwk is a single JSON Web Key as described by RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// key is a parsed verification key.
+type key struct {
+	kid    string
+	alg    string
+	public any
+}
+
+// KeySet holds the verification keys loaded from a local JWKS file and reloads them when the file changes.
+type KeySet struct {
+	path    string
+	mu      sync.RWMutex
+	keys    []key
+	modTime time.Time
+}
+
+// NewKeySet loads the JWKS document found at path.
+func NewKeySet(path string) (*KeySet, error) {
+	ks := &KeySet{path: path}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload re-reads the JWKS file and atomically replaces the current keys.
+func (ks *KeySet) Reload() error {
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return fmt.Errorf("stat jwks: %w", err)
+	}
+
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// Watch polls the JWKS file and reloads it whenever its modification time changes, until ctx is done.
+// A file that fails to load leaves the previous keys in place.
+func (ks *KeySet) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(ks.path)
+			if err != nil {
+				slog.Warn("jwks stat failed", "path", ks.path, "error", err)
+				continue
+			}
+
+			ks.mu.RLock()
+			changed := !info.ModTime().Equal(ks.modTime)
+			ks.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err := ks.Reload(); err != nil {
+				slog.Error("jwks reload failed, keeping previous keys", "path", ks.path, "error", err)
+				continue
+			}
+			slog.Info("jwks reloaded", "path", ks.path)
+		}
+	}
+}
+
+// lookup returns the key identified by kid that may be used with alg. When kid is empty the
+// first key compatible with alg is returned.
+func (ks *KeySet) lookup(kid, alg string) (*key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for i := range ks.keys {
+		k := ks.keys[i]
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if !algMatchesKey(alg, k.public) {
+			continue
+		}
+		return &k, nil
+	}
+
+	return nil, &KeyNotFoundError{kid: kid}
+}
+
+func algMatchesKey(alg string, public any) bool {
+	switch public.(type) {
+	case []byte:
+		return alg == algHS256
+	case *rsa.PublicKey:
+		return alg == algRS256
+	case *ecdsa.PublicKey:
+		return alg == algES256
+	default:
+		return false
+	}
+}
+
+func parseJWKS(data []byte) ([]key, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make([]key, 0, len(doc.Keys))
+	for _, j := range doc.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+
+		public, err := j.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", j.Kid, err)
+		}
+
+		keys = append(keys, key{kid: j.Kid, alg: j.Alg, public: public})
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+
+	return keys, nil
+}
+
+func (j jwk) publicKey() (any, error) {
+	switch j.Kty {
+	case "oct":
+		k, err := decodeSegment(j.K)
+		if err != nil || len(k) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return k, nil
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, errors.New("invalid RSA modulus")
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, errors.New("invalid EC x coordinate")
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, errors.New("invalid EC y coordinate")
+		}
+		if x.BitLen() > 256 || y.BitLen() > 256 {
+			return nil, errors.New("EC point is not on curve")
+		}
+		point := make([]byte, 65)
+		point[0] = 4
+		x.FillBytes(point[1:33])
+		y.FillBytes(point[33:])
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
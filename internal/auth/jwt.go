@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+	algES256 = "ES256"
+)
+
+// Claims are the registered JWT claims checked by the Verifier, plus the roles granted by the issuer.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	IssuedAt  int64    `json:"iat"`
+	Roles     []string `json:"roles"`
+}
+
+// audience accepts both the single string and the array form of the aud claim.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verifier validates compact-serialized JWTs signed with HS256, RS256 or ES256.
+type Verifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier creates a Verifier for tokens signed by keys in ks. Empty issuer or audience disables the respective check.
+func NewVerifier(ks *KeySet, issuer, audience string, leeway time.Duration) *Verifier {
+	return &Verifier{
+		keys:     ks,
+		issuer:   issuer,
+		audience: audience,
+		leeway:   leeway,
+		now:      time.Now,
+	}
+}
+
+// Verify checks the token signature and its registered claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, &InvalidTokenError{message: "malformed token"}
+	}
+
+	var hdr header
+	if err := decodeJSONSegment(parts[0], &hdr); err != nil {
+		return nil, &InvalidTokenError{message: "malformed token header"}
+	}
+
+	k, err := v.keys.lookup(hdr.Kid, hdr.Alg)
+	if err != nil {
+		return nil, &InvalidTokenError{message: err.Error()}
+	}
+
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, &InvalidTokenError{message: "malformed token signature"}
+	}
+
+	if !verifySignature(hdr.Alg, k.public, parts[0]+"."+parts[1], sig) {
+		return nil, &InvalidTokenError{message: "invalid token signature"}
+	}
+
+	var claims Claims
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, &InvalidTokenError{message: "malformed token claims"}
+	}
+
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (v *Verifier) validateClaims(c *Claims) error {
+	now := v.now()
+
+	if c.ExpiresAt == 0 {
+		return &InvalidTokenError{message: "token has no expiry"}
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(v.leeway)) {
+		return &InvalidTokenError{message: "token has expired"}
+	}
+	if c.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return &InvalidTokenError{message: "token is not valid yet"}
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return &InvalidTokenError{message: "unexpected token issuer"}
+	}
+	if v.audience != "" && !slices.Contains(c.Audience, v.audience) {
+		return &InvalidTokenError{message: "unexpected token audience"}
+	}
+	if c.Subject == "" {
+		return &InvalidTokenError{message: "token has no subject"}
+	}
+
+	return nil
+}
+
+func verifySignature(alg string, public any, signingInput string, sig []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case algHS256:
+		secret, ok := public.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(sig, mac.Sum(nil))
+	case algRS256:
+		pub, ok := public.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case algES256:
+		pub, ok := public.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+func decodeJSONSegment(seg string, v any) error {
+	data, err := decodeSegment(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testRSAKey *rsa.PrivateKey
+	testECKey  *ecdsa.PrivateKey
+)
+
+func init() {
+	var err error
+	if testRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+	if testECKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		panic(err)
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeTestJWKS(t *testing.T) string {
+	t.Helper()
+	doc := map[string]any{
+		"keys": []map[string]string{
+			{"kty": "oct", "kid": "hs", "alg": "HS256", "k": b64(testSecret)},
+			{
+				"kty": "RSA", "kid": "rs", "alg": "RS256",
+				"n": b64(testRSAKey.N.Bytes()), "e": b64(big.NewInt(int64(testRSAKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "es", "crv": "P-256",
+				"x": b64(testECKey.X.FillBytes(make([]byte, 32))), "y": b64(testECKey.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func signTestToken(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	hdr, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	input := b64(hdr) + "." + b64(body)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case algHS256:
+		mac := hmac.New(sha256.New, testSecret)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case algRS256:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case algES256:
+		r, s, err := ecdsa.Sign(rand.Reader, testECKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(sig)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+	ks, err := NewKeySet(writeTestJWKS(t))
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	valid := func() map[string]any {
+		return map[string]any{"iss": "issuer", "aud": "api", "sub": "42", "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(k string, v any) map[string]any {
+		c := valid()
+		c[k] = v
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		token  func(t *testing.T) string
+		errMsg string
+	}{
+		{
+			name:  "Accepts HS256 token",
+			token: func(t *testing.T) string { return signTestToken(t, algHS256, "hs", valid()) },
+		},
+		{
+			name:  "Accepts RS256 token",
+			token: func(t *testing.T) string { return signTestToken(t, algRS256, "rs", valid()) },
+		},
+		{
+			name:  "Accepts ES256 token without kid",
+			token: func(t *testing.T) string { return signTestToken(t, algES256, "", valid()) },
+		},
+		{
+			name: "Accepts audience array",
+			token: func(t *testing.T) string {
+				return signTestToken(t, algHS256, "hs", with("aud", []string{"other", "api"}))
+			},
+		},
+		{
+			name: "Rejects tampered signature",
+			token: func(t *testing.T) string {
+				orig := strings.Split(signTestToken(t, algHS256, "hs", valid()), ".")
+				forged := strings.Split(signTestToken(t, algHS256, "hs", with("sub", "1")), ".")
+				return orig[0] + "." + forged[1] + "." + orig[2]
+			},
+			errMsg: "invalid token signature",
+		},
+		{
+			name:   "Rejects algorithm not bound to key",
+			token:  func(t *testing.T) string { return signTestToken(t, algHS256, "rs", valid()) },
+			errMsg: "signing key rs not found",
+		},
+		{
+			name: "Rejects expired token",
+			token: func(t *testing.T) string {
+				return signTestToken(t, algHS256, "hs", with("exp", now.Add(-time.Hour).Unix()))
+			},
+			errMsg: "token has expired",
+		},
+		{
+			name: "Rejects token that is not valid yet",
+			token: func(t *testing.T) string {
+				return signTestToken(t, algHS256, "hs", with("nbf", now.Add(time.Hour).Unix()))
+			},
+			errMsg: "token is not valid yet",
+		},
+		{
+			name:   "Rejects wrong issuer",
+			token:  func(t *testing.T) string { return signTestToken(t, algHS256, "hs", with("iss", "someone")) },
+			errMsg: "unexpected token issuer",
+		},
+		{
+			name:   "Rejects wrong audience",
+			token:  func(t *testing.T) string { return signTestToken(t, algHS256, "hs", with("aud", "other")) },
+			errMsg: "unexpected token audience",
+		},
+		{
+			name:   "Rejects malformed token",
+			token:  func(_ *testing.T) string { return "not-a-token" },
+			errMsg: "malformed token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			v := NewVerifier(ks, "issuer", "api", time.Minute)
+			v.now = func() time.Time { return now }
+
+			claims, err := v.Verify(tt.token(t))
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.Subject != "42" {
+					t.Errorf("Verify() subject = %v, want 42", claims.Subject)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.errMsg {
+				t.Errorf("Verify() error = %v, errMsg %v", err, tt.errMsg)
+			}
+		})
+	}
+}
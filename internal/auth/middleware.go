@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// Authenticator resolves the caller identity from request credentials. It returns ErrNoCredentials
+// when the request carries no credentials it understands.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// JWTAuthenticator authenticates requests bearing a JWT in the Authorization header.
+type JWTAuthenticator struct {
+	verifier *Verifier
+}
+
+func NewJWTAuthenticator(v *Verifier) *JWTAuthenticator {
+	return &JWTAuthenticator{verifier: v}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	id := NewIdentity(claims.Issuer, claims.Subject)
+	id.Method = MethodBearer
+	id.Roles = claims.Roles
+
+	return id, nil
+}
+
+// Middleware authenticates requests that present credentials and stores the caller identity in the request
+// context. Requests presenting invalid credentials are rejected; anonymous requests are passed through so that
+// the OpenAPI validator can decide, per operation, whether authentication is required.
+func Middleware(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				id, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				var csrf *CSRFError
+				if errors.As(err, &csrf) {
+					problem.Error(w, r, http.StatusForbidden, err.Error())
+					return
+				}
+				if err != nil {
+					slog.DebugContext(r.Context(), "authentication failed", "error", err)
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					problem.Error(w, r, http.StatusUnauthorized, err.Error())
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ValidateSecurity is an openapi3filter.AuthenticationFunc that requires a caller authenticated with the
+// method matching the security scheme declared by the operation.
+func ValidateSecurity(_ context.Context, input *openapi3filter.AuthenticationInput) error {
+	id, ok := IdentityFromContext(input.RequestValidationInput.Request.Context())
+	if !ok {
+		return input.NewError(errors.New("authentication required"))
+	}
+
+	scheme := input.SecurityScheme
+	switch {
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+		// Client certificates, verified during the TLS handshake, stand in for bearer credentials.
+		ok = id.Method == MethodBearer || id.Method == MethodToken || id.Method == MethodCertificate
+	case scheme.Type == "apiKey" && scheme.In == "cookie":
+		ok = id.Method == MethodSession
+	default:
+		ok = false
+	}
+	if !ok {
+		return input.NewError(fmt.Errorf("credentials do not satisfy %s", input.SecuritySchemeName))
+	}
+
+	if !id.HasScopes(input.Scopes) {
+		return input.NewError(fmt.Errorf("token lacks required scopes %v", input.Scopes))
+	}
+
+	return nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
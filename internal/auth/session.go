@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jqdurham/rest-sample/internal/session"
+)
+
+const (
+	// SessionCookie is the name of the cookie carrying the session token.
+	SessionCookie = "session"
+	// CSRFCookie exposes the CSRF token to scripts of the client origin, which must echo it in CSRFHeader.
+	CSRFCookie = "csrf_token"
+	// CSRFHeader carries the CSRF token that must accompany cookie-authenticated writes.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// SessionAuthenticator authenticates requests carrying a session cookie. Unsafe methods must also echo the
+// session's CSRF token in the CSRFHeader.
+type SessionAuthenticator struct {
+	sessions session.Servicer
+}
+
+func NewSessionAuthenticator(sessions session.Servicer) *SessionAuthenticator {
+	return &SessionAuthenticator{sessions: sessions}
+}
+
+func (a *SessionAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sess, err := a.sessions.GetSession(cookie.Value)
+	if err != nil {
+		// Browsers keep sending cookies of sessions that expired server side, treat them as absent so that
+		// the caller is still able to log in again.
+		var nf *session.NotFoundError
+		if errors.As(err, &nf) {
+			return nil, ErrNoCredentials
+		}
+		return nil, err
+	}
+
+	if !isSafeMethod(r.Method) {
+		csrf := r.Header.Get(CSRFHeader)
+		if csrf == "" || subtle.ConstantTimeCompare([]byte(csrf), []byte(sess.CSRFToken)) != 1 {
+			return nil, &CSRFError{}
+		}
+	}
+
+	return &Identity{
+		Subject: strconv.FormatInt(sess.UserID, 10),
+		UserID:  sess.UserID,
+		Method:  MethodSession,
+	}, nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jqdurham/rest-sample/internal/token"
+)
+
+// TokenAuthenticator authenticates requests bearing a personal access token in the Authorization header.
+// It must run before the JWTAuthenticator, which would reject personal access tokens as malformed JWTs.
+type TokenAuthenticator struct {
+	tokens token.Servicer
+}
+
+func NewTokenAuthenticator(tokens token.Servicer) *TokenAuthenticator {
+	return &TokenAuthenticator{tokens: tokens}
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	secret, ok := bearerToken(r)
+	if !ok || !strings.HasPrefix(secret, token.Prefix) {
+		return nil, ErrNoCredentials
+	}
+
+	tkn, err := a.tokens.Authenticate(secret)
+	if err != nil {
+		var af *token.AuthenticationError
+		if errors.As(err, &af) {
+			return nil, &InvalidTokenError{message: af.Error()}
+		}
+		return nil, err
+	}
+
+	return &Identity{
+		Subject: strconv.FormatInt(tkn.UserID, 10),
+		UserID:  tkn.UserID,
+		Method:  MethodToken,
+		Scopes:  tkn.Scopes,
+	}, nil
+}
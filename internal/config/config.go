@@ -0,0 +1,260 @@
+// Package config loads the server configuration. Settings are taken from, in increasing order of precedence,
+// built-in defaults, a YAML or JSON config file, REST_* environment variables and command line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jqdurham/rest-sample/internal/accesslog"
+	"github.com/jqdurham/rest-sample/internal/api"
+	"github.com/jqdurham/rest-sample/internal/cors"
+	"github.com/jqdurham/rest-sample/internal/lifecycle"
+	"github.com/jqdurham/rest-sample/internal/listener"
+	"github.com/jqdurham/rest-sample/internal/loadshed"
+	"github.com/jqdurham/rest-sample/internal/logging"
+	"github.com/jqdurham/rest-sample/internal/post"
+	"github.com/jqdurham/rest-sample/internal/redact"
+	"github.com/jqdurham/rest-sample/internal/tlsconfig"
+	"github.com/jqdurham/rest-sample/internal/tracing"
+)
+
+// Config holds every setting of the server.
+type Config struct {
+	Server    Server           `yaml:"server"`
+	Log       Log              `yaml:"log"`
+	AccessLog AccessLog        `yaml:"access_log"`
+	Redact    redact.Config    `yaml:"redact"`
+	Auth      Auth             `yaml:"auth"`
+	RateLimit RateLimit        `yaml:"rate_limit"`
+	PostQuota post.Quota       `yaml:"post_quota"`
+	LoadShed  loadshed.Config  `yaml:"load_shedding"`
+	CORS      cors.Config      `yaml:"cors"`
+	Tracing   Tracing          `yaml:"tracing"`
+	Shutdown  lifecycle.Config `yaml:"shutdown"`
+
+	// file is the config file the configuration was loaded from, if any.
+	file string
+}
+
+// Server configures the listeners and the HTTP server.
+type Server struct {
+	// Addrs are TCP addresses or unix: sockets the API is served on. Sockets passed by systemd replace them.
+	Addrs     []string `yaml:"addrs"`
+	AdminAddr string   `yaml:"admin_addr"`
+	// SocketMode is the octal permission of the unix domain sockets created, e.g. 0660.
+	SocketMode  string `yaml:"socket_mode"`
+	SocketGroup string `yaml:"socket_group"`
+	// ReadHeaderTimeout bounds reading request headers, ReadTimeout the whole request.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	// IdleTimeout is how long keep-alive connections wait for the next request.
+	IdleTimeout    time.Duration    `yaml:"idle_timeout"`
+	MaxHeaderBytes int              `yaml:"max_header_bytes"`
+	MaxBodyBytes   int64            `yaml:"max_body_bytes"`
+	TLS            tlsconfig.Config `yaml:"tls"`
+	// UpgradeTimeout bounds the wait for the new binary to start, and then to serve, when upgrading on SIGUSR2.
+	UpgradeTimeout time.Duration `yaml:"upgrade_timeout"`
+}
+
+type Log struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+	File   string `yaml:"file"`
+}
+
+type AccessLog struct {
+	accesslog.Config `yaml:",inline"`
+	RotateInterval   time.Duration `yaml:"rotate_interval"`
+}
+
+type Auth struct {
+	JWKSFile    string `yaml:"jwks_file"`
+	JWTIssuer   string `yaml:"jwt_issuer"`
+	JWTAudience string `yaml:"jwt_audience"`
+	PolicyFile  string `yaml:"policy_file"`
+}
+
+type RateLimit struct {
+	File string `yaml:"file"`
+}
+
+type Tracing struct {
+	tracing.Config `yaml:",inline"`
+	ServerTiming   bool `yaml:"server_timing"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addrs:             []string{":8080"},
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      api.DefaultMaxBodyBytes,
+			UpgradeTimeout:    30 * time.Second,
+			TLS:               tlsconfig.Config{MinVersion: "1.2", ClientAuth: tlsconfig.ClientAuthNone},
+		},
+		Log: Log{Format: logging.FormatConsole, Level: "debug"},
+		AccessLog: AccessLog{
+			Config: accesslog.Config{
+				Format:    "combined",
+				MaxSizeMB: 100,
+				MaxAge:    28 * 24 * time.Hour,
+				Compress:  true,
+			},
+			RotateInterval: 24 * time.Hour,
+		},
+		Redact:    redactDefaults(),
+		PostQuota: post.DefaultQuota,
+		LoadShed:  loadshed.Config{MaxInFlight: 256, MaxQueue: 128, QueueTimeout: time.Second},
+		CORS: cors.Config{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-CSRF-Token"},
+			ExposedHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Tracing: Tracing{
+			Config: tracing.Config{Exporter: tracing.ExporterNone, SampleRatio: 1, ServiceName: "rest-sample"},
+		},
+		Shutdown: lifecycle.Config{DrainTimeout: 30 * time.Second, HookTimeout: 10 * time.Second},
+	}
+}
+
+// Listener returns the options of the unix domain sockets listened on.
+func (s Server) Listener() listener.Options {
+	// Validated by Config.Validate.
+	mode, _ := listener.ParseMode(s.SocketMode)
+	return listener.Options{SocketMode: mode, SocketGroup: s.SocketGroup}
+}
+
+// redactDefaults copies redact.DefaultConfig, so that flags and the config file cannot alter its lists.
+func redactDefaults() redact.Config {
+	c := redact.DefaultConfig
+	c.QueryParams = slices.Clone(c.QueryParams)
+	c.Headers = slices.Clone(c.Headers)
+	c.Fields = slices.Clone(c.Fields)
+	return c
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+func invalid(format string, args ...any) error {
+	return &InvalidError{message: fmt.Sprintf(format, args...)}
+}
+
+// Validate reports every invalid setting.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, invalid(format, args...))
+		}
+	}
+
+	check(len(c.Server.Addrs) > 0, "server.addrs is required")
+	if _, err := listener.ParseMode(c.Server.SocketMode); err != nil {
+		errs = append(errs, err)
+	}
+	for _, d := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"access_log.max_age", c.AccessLog.MaxAge},
+		{"access_log.rotate_interval", c.AccessLog.RotateInterval},
+		{"post_quota.window", c.PostQuota.Window},
+		{"load_shedding.queue_timeout", c.LoadShed.QueueTimeout},
+		{"cors.max_age", c.CORS.MaxAge},
+		{"shutdown.pre_stop_delay", c.Shutdown.PreStopDelay},
+		{"shutdown.drain_timeout", c.Shutdown.DrainTimeout},
+		{"shutdown.hook_timeout", c.Shutdown.HookTimeout},
+	} {
+		check(d.d >= 0, "%s must not be negative", d.name)
+	}
+	for _, n := range []struct {
+		name string
+		n    int64
+	}{
+		{"server.max_header_bytes", int64(c.Server.MaxHeaderBytes)},
+		{"access_log.max_size_mb", int64(c.AccessLog.MaxSizeMB)},
+		{"access_log.max_backups", int64(c.AccessLog.MaxBackups)},
+		{"post_quota.window_limit", int64(c.PostQuota.WindowLimit)},
+		{"post_quota.max_posts", int64(c.PostQuota.MaxPosts)},
+		{"load_shedding.max_in_flight", int64(c.LoadShed.MaxInFlight)},
+		{"load_shedding.max_queue", int64(c.LoadShed.MaxQueue)},
+	} {
+		check(n.n >= 0, "%s must not be negative", n.name)
+	}
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive")
+	check(c.Server.UpgradeTimeout > 0, "server.upgrade_timeout must be positive")
+	check(c.PostQuota.WindowLimit == 0 || c.PostQuota.Window > 0, "post_quota.window is required by post_quota.window_limit")
+
+	switch c.Log.Format {
+	case logging.FormatConsole, logging.FormatJSON, logging.FormatLogfmt:
+	default:
+		check(false, "log.format must be one of console, json or logfmt")
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, err)
+	}
+
+	switch c.Redact.IPMode {
+	case redact.IPKeep, redact.IPTruncate, redact.IPHash:
+	default:
+		check(false, "redact.ip_mode must be one of keep, truncate or hash")
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	case tracing.ExporterFile:
+		check(c.Tracing.File != "", "tracing.file is required by the file exporter")
+	default:
+		check(false, "tracing.exporter must be one of none, otlp, stdout or file")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	if err := c.CORS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Server.TLS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// YAML encodes the configuration in the format of the config file.
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// Values returns the configuration keyed as in the config file, with durations as strings, for JSON encoding.
+func (c *Config) Values() (map[string]any, error) {
+	b, err := c.YAML()
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
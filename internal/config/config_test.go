@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rest.yaml")
+	err := os.WriteFile(file, []byte(`
+server:
+  addrs: [":9000"]
+  read_timeout: 10s
+log:
+  level: warn
+  format: json
+cors:
+  allowed_origins: [https://file.example.com]
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if !slices.Equal(cfg.Server.Addrs, []string{":8080"}) || cfg.Server.ReadHeaderTimeout != 5*time.Second || cfg.Log.Level != "debug" {
+					t.Errorf("unexpected defaults: %+v", cfg.Server)
+				}
+			},
+		},
+		{
+			name: "file overrides defaults",
+			args: []string{"--config", file},
+			check: func(t *testing.T, cfg *Config) {
+				if !slices.Equal(cfg.Server.Addrs, []string{":9000"}) || cfg.Server.ReadTimeout != 10*time.Second || cfg.Log.Format != "json" {
+					t.Errorf("file not applied: %+v %+v", cfg.Server, cfg.Log)
+				}
+				if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+					t.Errorf("write timeout = %v, want the default", cfg.Server.WriteTimeout)
+				}
+			},
+		},
+		{
+			name: "env overrides file",
+			env:  map[string]string{"REST_CONFIG": file, "REST_LOG_LEVEL": "info", "REST_CORS_ORIGINS": "https://a.com, https://b.com"},
+			check: func(t *testing.T, cfg *Config) {
+				if !slices.Equal(cfg.Server.Addrs, []string{":9000"}) || cfg.Log.Level != "info" {
+					t.Errorf("env not applied: %+v %+v", cfg.Server, cfg.Log)
+				}
+				if want := []string{"https://a.com", "https://b.com"}; !slices.Equal(cfg.CORS.AllowedOrigins, want) {
+					t.Errorf("origins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+				}
+			},
+		},
+		{
+			name: "flags override env",
+			args: []string{"--config", file, "--log-level", "error"},
+			env:  map[string]string{"REST_LOG_LEVEL": "info"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Log.Level != "error" {
+					t.Errorf("level = %q, want error", cfg.Log.Level)
+				}
+			},
+		},
+		{
+			name:    "invalid env",
+			env:     map[string]string{"REST_READ_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid setting",
+			args:    []string{"--trace-sample-ratio", "2"},
+			wantErr: true,
+		},
+		{
+			name:    "credentials for any origin",
+			args:    []string{"--cors-origins", "*", "--cors-credentials"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"--config", filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			cfg, _, err := Load("rest", tt.args, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rest.json")
+	if err := os.WriteFile(file, []byte(`{"server": {"adress": ":9000"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load("rest", []string{"--config", file}, func(string) (string, bool) { return "", false }); err == nil {
+		t.Fatal("Load() accepted an unknown key")
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	_, _, err := Load("rest", []string{"-h"}, func(string) (string, bool) { return "", false })
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Load() error = %v, want flag.ErrHelp", err)
+	}
+}
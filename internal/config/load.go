@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables overriding flags: --access-log-format is read from
+// REST_ACCESS_LOG_FORMAT.
+const EnvPrefix = "REST_"
+
+// Load builds the configuration from defaults, the config file named by --config or REST_CONFIG, the environment
+// and args, each overriding the previous. It reports whether --print-config was given. flag.ErrHelp is returned
+// when args ask for usage.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, bool, error) {
+	cfg := Default()
+	var (
+		file        string
+		printConfig bool
+	)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&file, "config", "", "Path to a YAML or JSON config file")
+	fs.BoolVar(&printConfig, "print-config", false, "Print the effective configuration as YAML and exit")
+	bind(fs, &cfg)
+
+	// Flags are parsed twice: first to find the config file, then again so that they override the file and the
+	// environment.
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if file == "" {
+		file, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+
+	cfg = Default()
+	cfg.file = file
+	if file != "" {
+		if err := decodeFile(file, &cfg); err != nil {
+			return nil, false, err
+		}
+	}
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		key := EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if v, ok := lookupEnv(key); ok {
+			if err := f.Value.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, false, err
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, false, err
+	}
+	return &cfg, printConfig, nil
+}
+
+// decodeFile reads a config file. JSON is decoded as the YAML subset it is. Unknown keys are rejected, so that
+// typos do not silently fall back to defaults.
+func decodeFile(path string, cfg *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decode config %s: %w", path, err)
+	}
+	return nil
+}
+
+// bind defines the flags overriding cfg. Their defaults are the values cfg holds.
+func bind(fs *flag.FlagSet, cfg *Config) {
+	fs.Var((*list)(&cfg.Server.Addrs), "addr", "Comma separated listen addresses, e.g. :8080,unix:/run/rest/api.sock")
+	fs.StringVar(&cfg.Server.SocketMode, "socket-mode", cfg.Server.SocketMode, "Octal permissions of unix domain sockets, e.g. 0660")
+	fs.StringVar(&cfg.Server.SocketGroup, "socket-group", cfg.Server.SocketGroup, "Group owning unix domain sockets")
+	fs.StringVar(&cfg.Server.AdminAddr, "admin-addr", cfg.Server.AdminAddr,
+		"Admin listen address, e.g. 127.0.0.1:9090 or unix:/run/rest/admin.sock (empty disables the admin listener)")
+	fs.DurationVar(&cfg.Server.ReadHeaderTimeout, "read-header-timeout", cfg.Server.ReadHeaderTimeout, "Time to read request headers")
+	fs.DurationVar(&cfg.Server.ReadTimeout, "read-timeout", cfg.Server.ReadTimeout, "Time to read a whole request (0 disables)")
+	fs.DurationVar(&cfg.Server.WriteTimeout, "write-timeout", cfg.Server.WriteTimeout, "Time to write a response (0 disables)")
+	fs.DurationVar(&cfg.Server.IdleTimeout, "idle-timeout", cfg.Server.IdleTimeout, "Time keep-alive connections wait for a request")
+	fs.IntVar(&cfg.Server.MaxHeaderBytes, "max-header-bytes", cfg.Server.MaxHeaderBytes, "Maximum size of request headers")
+	fs.Int64Var(&cfg.Server.MaxBodyBytes, "max-body-bytes", cfg.Server.MaxBodyBytes, "Maximum size of request bodies")
+	fs.StringVar(&cfg.Server.TLS.Cert, "tls-cert", cfg.Server.TLS.Cert,
+		"PEM certificate chain served over TLS, reloaded when it changes (empty disables TLS)")
+	fs.StringVar(&cfg.Server.TLS.Key, "tls-key", cfg.Server.TLS.Key, "PEM private key of the TLS certificate")
+	fs.StringVar(&cfg.Server.TLS.MinVersion, "tls-min-version", cfg.Server.TLS.MinVersion, "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3")
+	fs.Var((*list)(&cfg.Server.TLS.CipherSuites), "tls-cipher-suites",
+		"Comma separated TLS 1.0-1.2 cipher suites (empty selects Go's defaults)")
+	fs.StringVar(&cfg.Server.TLS.ClientCA, "tls-client-ca", cfg.Server.TLS.ClientCA,
+		"PEM bundle of the CAs client certificates are verified against")
+	fs.StringVar(&cfg.Server.TLS.ClientAuth, "tls-client-auth", cfg.Server.TLS.ClientAuth, "Client certificates: none, optional or require")
+	fs.StringVar(&cfg.Server.TLS.RedirectAddr, "tls-redirect-addr", cfg.Server.TLS.RedirectAddr,
+		"Address on which plain HTTP requests are redirected to HTTPS, e.g. :80")
+	fs.DurationVar(&cfg.Server.UpgradeTimeout, "upgrade-timeout", cfg.Server.UpgradeTimeout,
+		"Time the new binary may take to start, and then to serve, when upgrading on SIGUSR2")
+
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "Log format: console, json or logfmt")
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "Minimum level logged: debug, info, warn or error (SIGUSR1 toggles debug)")
+	fs.StringVar(&cfg.Log.File, "log-file", cfg.Log.File, "Append logs to this file instead of stderr")
+
+	fs.StringVar(&cfg.AccessLog.File, "access-log", cfg.AccessLog.File, "Write an access log to this file (empty disables it)")
+	fs.StringVar(&cfg.AccessLog.Format, "access-log-format", cfg.AccessLog.Format,
+		"Access log format: common, combined or Apache mod_log_config directives")
+	fs.IntVar(&cfg.AccessLog.MaxSizeMB, "access-log-max-size", cfg.AccessLog.MaxSizeMB, "Size in megabytes at which the access log is rotated")
+	fs.DurationVar(&cfg.AccessLog.MaxAge, "access-log-max-age", cfg.AccessLog.MaxAge, "Time rotated access logs are kept (0 keeps them)")
+	fs.IntVar(&cfg.AccessLog.MaxBackups, "access-log-max-backups", cfg.AccessLog.MaxBackups, "Rotated access logs kept (0 keeps them all)")
+	fs.BoolVar(&cfg.AccessLog.Compress, "access-log-compress", cfg.AccessLog.Compress, "Compress rotated access logs")
+	fs.DurationVar(&cfg.AccessLog.RotateInterval, "access-log-rotate-interval", cfg.AccessLog.RotateInterval,
+		"Interval at which the access log is rotated (0 disables)")
+
+	fs.Var((*list)(&cfg.Redact.QueryParams), "redact-query-params", "Comma separated query parameters masked in logs")
+	fs.Var((*list)(&cfg.Redact.Headers), "redact-headers", "Comma separated headers masked in logs")
+	fs.Var((*list)(&cfg.Redact.Fields), "redact-fields", "Comma separated log attributes and JSON fields masked in logs")
+	fs.StringVar(&cfg.Redact.IPMode, "redact-ip", cfg.Redact.IPMode, "Client IPs in logs: keep, truncate or hash")
+	fs.BoolVar(&cfg.Redact.Emails, "redact-emails", cfg.Redact.Emails, "Mask email addresses in logs and validation errors")
+
+	fs.StringVar(&cfg.Auth.JWKSFile, "jwks-file", cfg.Auth.JWKSFile, "Path to JWKS file with keys used to verify bearer tokens")
+	fs.StringVar(&cfg.Auth.JWTIssuer, "jwt-issuer", cfg.Auth.JWTIssuer, "Required JWT issuer (iss) claim")
+	fs.StringVar(&cfg.Auth.JWTAudience, "jwt-audience", cfg.Auth.JWTAudience, "Required JWT audience (aud) claim")
+	fs.StringVar(&cfg.Auth.PolicyFile, "policy-file", cfg.Auth.PolicyFile, "Path to access policy file (defaults to the built-in policy)")
+	fs.StringVar(&cfg.RateLimit.File, "rate-limit-file", cfg.RateLimit.File, "Path to rate limit file (defaults to the built-in limits)")
+
+	fs.IntVar(&cfg.PostQuota.WindowLimit, "post-quota", cfg.PostQuota.WindowLimit, "Posts a user may create per quota window (0 disables)")
+	fs.DurationVar(&cfg.PostQuota.Window, "post-quota-window", cfg.PostQuota.Window, "Sliding window of the post quota")
+	fs.IntVar(&cfg.PostQuota.MaxPosts, "post-quota-max", cfg.PostQuota.MaxPosts, "Posts a user may author in total (0 disables)")
+
+	fs.IntVar(&cfg.LoadShed.MaxInFlight, "max-in-flight", cfg.LoadShed.MaxInFlight,
+		"Requests served concurrently before queueing (0 disables load shedding)")
+	fs.IntVar(&cfg.LoadShed.MaxQueue, "max-queue", cfg.LoadShed.MaxQueue, "Requests waiting for a free slot before shedding")
+	fs.DurationVar(&cfg.LoadShed.QueueTimeout, "queue-timeout", cfg.LoadShed.QueueTimeout, "Time a request may wait for a free slot")
+
+	fs.Var((*list)(&cfg.CORS.AllowedOrigins), "cors-origins",
+		"Comma separated origins allowed to call the API, e.g. https://*.example.com (empty disables CORS)")
+	fs.Var((*list)(&cfg.CORS.AllowedMethods), "cors-methods", "Comma separated methods allowed for cross-origin requests")
+	fs.Var((*list)(&cfg.CORS.AllowedHeaders), "cors-headers", "Comma separated request headers allowed for cross-origin requests")
+	fs.Var((*list)(&cfg.CORS.ExposedHeaders), "cors-expose-headers", "Comma separated response headers exposed to cross-origin callers")
+	fs.BoolVar(&cfg.CORS.AllowCredentials, "cors-credentials", cfg.CORS.AllowCredentials, "Allow cross-origin requests with cookies")
+	fs.DurationVar(&cfg.CORS.MaxAge, "cors-max-age", cfg.CORS.MaxAge, "Time browsers may cache preflight responses")
+
+	fs.DurationVar(&cfg.Shutdown.PreStopDelay, "shutdown-delay", cfg.Shutdown.PreStopDelay,
+		"Time readiness fails before connections are drained on shutdown")
+	fs.DurationVar(&cfg.Shutdown.DrainTimeout, "shutdown-timeout", cfg.Shutdown.DrainTimeout,
+		"Time in-flight requests may take to finish on shutdown")
+	fs.DurationVar(&cfg.Shutdown.HookTimeout, "shutdown-hook-timeout", cfg.Shutdown.HookTimeout, "Time each shutdown hook may take")
+
+	fs.StringVar(&cfg.Tracing.Exporter, "trace-exporter", cfg.Tracing.Exporter, "Trace exporter: none, otlp, stdout or file")
+	fs.StringVar(&cfg.Tracing.Endpoint, "trace-endpoint", cfg.Tracing.Endpoint, "OTLP/HTTP collector address, e.g. localhost:4318")
+	fs.BoolVar(&cfg.Tracing.Insecure, "trace-insecure", cfg.Tracing.Insecure, "Send traces to the OTLP collector without TLS")
+	fs.StringVar(&cfg.Tracing.File, "trace-file", cfg.Tracing.File, "File written by the file trace exporter")
+	fs.Float64Var(&cfg.Tracing.SampleRatio, "trace-sample-ratio", cfg.Tracing.SampleRatio, "Fraction of new traces recorded")
+	fs.StringVar(&cfg.Tracing.ServiceName, "trace-service-name", cfg.Tracing.ServiceName, "Service name of exported spans")
+	fs.BoolVar(&cfg.Tracing.ServerTiming, "server-timing", cfg.Tracing.ServerTiming,
+		"Summarize validation, storage and encoding time in a Server-Timing header")
+}
+
+// list is a comma separated flag value. Empty elements are dropped.
+type list []string
+
+func (l *list) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
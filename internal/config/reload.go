@@ -0,0 +1,188 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Change is a setting that differs between two configurations, keyed by its path in the config file, e.g.
+// cors.allowed_origins.
+type Change struct {
+	Key string
+	Old any
+	New any
+}
+
+// Diff lists the settings that differ between from and to, sorted by key.
+func Diff(from, to *Config) ([]Change, error) {
+	a, err := flatValues(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flatValues(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Change
+	for k, v := range b {
+		if old, ok := a[k]; !ok || !reflect.DeepEqual(old, v) {
+			out = append(out, Change{Key: k, Old: a[k], New: v})
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			out = append(out, Change{Key: k, Old: v})
+		}
+	}
+	slices.SortFunc(out, func(x, y Change) int { return strings.Compare(x.Key, y.Key) })
+	return out, nil
+}
+
+func flatValues(c *Config) (map[string]any, error) {
+	values, err := c.Values()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]any)
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			out[prefix+k] = v
+		}
+	}
+	flatten("", values)
+	return out, nil
+}
+
+// reloadable returns c with the settings of next that are applied without a restart: the log level, access
+// policy, rate limits, CORS, post quota and shutdown timeouts.
+func (c *Config) reloadable(next *Config) *Config {
+	out := *c
+	out.Log.Level = next.Log.Level
+	out.Auth.PolicyFile = next.Auth.PolicyFile
+	out.RateLimit = next.RateLimit
+	out.CORS = next.CORS
+	out.PostQuota = next.PostQuota
+	out.Shutdown = next.Shutdown
+	return &out
+}
+
+// Reloader re-reads the configuration and applies the settings that may change while the server runs.
+type Reloader struct {
+	load  func() (*Config, error)
+	apply func(*Config) error
+
+	mu      sync.Mutex
+	current atomic.Pointer[Config]
+	modTime time.Time
+}
+
+// NewReloader starts from the loaded cfg. load reads the configuration again, apply puts the reloadable settings
+// of a new configuration into effect, re-reading the files it names, and fails, leaving the running settings in
+// place, when they are unusable.
+func NewReloader(cfg *Config, load func() (*Config, error), apply func(*Config) error) *Reloader {
+	r := &Reloader{load: load, apply: apply}
+	r.current.Store(cfg)
+	r.modTime = r.fileModTime()
+	return r
+}
+
+// Current returns the configuration in effect.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Reload loads and validates the configuration, applies its reloadable settings and logs what changed. Changed
+// settings that need a restart are logged and otherwise ignored. An invalid configuration is rejected as a whole.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return err
+	}
+	current := r.current.Load()
+	applied := current.reloadable(next)
+
+	changes, err := Diff(current, applied)
+	if err != nil {
+		return err
+	}
+	pending, err := Diff(applied, next)
+	if err != nil {
+		return err
+	}
+
+	// Apply even without changes: files named by the configuration, such as the rate limits, may have changed.
+	if err := r.apply(applied); err != nil {
+		return err
+	}
+	r.current.Store(applied)
+	r.modTime = r.fileModTime()
+
+	for _, c := range changes {
+		slog.InfoContext(ctx, "config changed", slog.String("key", c.Key), slog.Any("old", c.Old), slog.Any("new", c.New))
+	}
+	for _, c := range pending {
+		slog.WarnContext(ctx, "config change requires a restart", slog.String("key", c.Key), slog.Any("old", c.Old),
+			slog.Any("new", c.New))
+	}
+	slog.InfoContext(ctx, "config reloaded", slog.Int("applied", len(changes)), slog.Int("pending_restart", len(pending)))
+	return nil
+}
+
+// Run reloads the configuration whenever a signal is received or the modification time of the config file
+// changes, polled every interval, until ctx is done. Failed reloads are logged and keep the configuration in
+// effect.
+func (r *Reloader) Run(ctx context.Context, signals <-chan os.Signal, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		case <-ticker.C:
+			// A file that fails to load is not retried until it changes again.
+			r.mu.Lock()
+			modTime := r.fileModTime()
+			changed := !modTime.Equal(r.modTime)
+			r.modTime = modTime
+			r.mu.Unlock()
+			if !changed {
+				continue
+			}
+		}
+
+		if err := r.Reload(ctx); err != nil {
+			slog.ErrorContext(ctx, "config reload failed, keeping current configuration", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// fileModTime returns the modification time of the config file, zero without one or when it cannot be read.
+func (r *Reloader) fileModTime() time.Time {
+	file := r.current.Load().file
+	if file == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReloaderReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rest.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() (*Config, error) {
+		cfg, _, err := Load("rest", []string{"--config", file}, func(string) (string, bool) { return "", false })
+		return cfg, err
+	}
+
+	write("server: {addrs: [':9000']}\nlog: {level: info}\n")
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var applied []*Config
+	failApply := false
+	r := NewReloader(cfg, load, func(next *Config) error {
+		if failApply {
+			return errors.New("unusable")
+		}
+		applied = append(applied, next)
+		return nil
+	})
+
+	write("server: {addrs: [':9001']}\nlog: {level: warn}\ncors: {allowed_origins: [https://a.com]}\n")
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	got := r.Current()
+	if got.Log.Level != "warn" || !slices.Equal(got.CORS.AllowedOrigins, []string{"https://a.com"}) {
+		t.Errorf("reloadable settings not applied: %+v %+v", got.Log, got.CORS)
+	}
+	if !slices.Equal(got.Server.Addrs, []string{":9000"}) {
+		t.Errorf("addrs = %q, want them kept until a restart", got.Server.Addrs)
+	}
+	if len(applied) != 1 || applied[0] != got {
+		t.Errorf("apply called with %v, want the current configuration", applied)
+	}
+
+	write("log: {level: loud}\n")
+	if err := r.Reload(context.Background()); err == nil {
+		t.Error("Reload() accepted an invalid configuration")
+	}
+	write("log: {level: error}\n")
+	failApply = true
+	if err := r.Reload(context.Background()); err == nil {
+		t.Error("Reload() ignored a failed apply")
+	}
+	if r.Current() != got {
+		t.Error("failed reload replaced the current configuration")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from, to := Default(), Default()
+	to.Server.Addrs = []string{":9000"}
+	to.CORS.AllowedOrigins = []string{"https://a.com"}
+
+	changes, err := Diff(&from, &to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, c := range changes {
+		keys = append(keys, c.Key)
+	}
+	if want := []string{"cors.allowed_origins", "server.addrs"}; !slices.Equal(keys, want) {
+		t.Errorf("Diff() keys = %v, want %v", keys, want)
+	}
+}
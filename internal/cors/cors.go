@@ -0,0 +1,153 @@
+// Package cors implements Cross-Origin Resource Sharing, answering preflight requests for the routes of the
+// OpenAPI document and annotating actual requests from allowed origins.
+package cors
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Config lists what cross-origin callers may do. Origins may be "*" or patterns such as
+// "https://*.example.com", matched with path.Match. Credentials cannot be allowed for any origin "*".
+type Config struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// MethodResolver lists the methods served for the path of a request, nil if the path is unknown.
+type MethodResolver func(r *http.Request) []string
+
+// Policy holds the active Config, which may be swapped while requests are served.
+type Policy struct {
+	cfg atomic.Pointer[Config]
+}
+
+func NewPolicy(cfg Config) *Policy {
+	p := &Policy{}
+	p.Swap(cfg)
+	return p
+}
+
+// Swap atomically replaces the active Config. A Config without allowed origins disables CORS.
+func (p *Policy) Swap(cfg Config) {
+	p.cfg.Store(&cfg)
+}
+
+// Middleware answers preflight requests for known paths and adds CORS headers to requests from allowed
+// origins. Requests from other origins are passed through unchanged, so browsers refuse their responses.
+func Middleware(cfg Config, methods MethodResolver) func(http.Handler) http.Handler {
+	return NewPolicy(cfg).Middleware(methods)
+}
+
+// Middleware is like the package level Middleware, applying the Config active when each request arrives.
+func (p *Policy) Middleware(methods MethodResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := p.cfg.Load()
+			origin := r.Header.Get("Origin")
+			if origin == "" || len(cfg.AllowedOrigins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			var served []string
+			if preflight {
+				if served = methods(r); served == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			if !cfg.allowsOrigin(origin) {
+				slog.WarnContext(r.Context(), "CORS origin not allowed", slog.String("origin", origin), slog.String("method", r.Method),
+					slog.String("path", r.URL.Path))
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if slices.Contains(cfg.AllowedOrigins, "*") {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if len(cfg.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", strings.Join(cfg.methodsFor(served), ", "))
+			if len(cfg.AllowedHeaders) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// Validate rejects malformed origin patterns and credentials allowed for any origin, which would let every site
+// make requests carrying the user's cookies.
+func (c Config) Validate() error {
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		return fmt.Errorf("CORS credentials cannot be allowed for origin %q", "*")
+	}
+	for _, pattern := range c.AllowedOrigins {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid CORS origin pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (c Config) allowsOrigin(origin string) bool {
+	for _, pattern := range c.AllowedOrigins {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(origin)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// methodsFor returns the served methods cross-origin callers may use.
+func (c Config) methodsFor(served []string) []string {
+	out := make([]string, 0, len(served))
+	for _, m := range served {
+		if slices.Contains(c.AllowedMethods, m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	cfg := Config{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet, http.MethodDelete},
+		AllowedHeaders: []string{"Authorization"},
+	}
+	methods := func(r *http.Request) []string {
+		if r.URL.Path != "/posts/1" {
+			return nil
+		}
+		return []string{http.MethodGet, http.MethodPut, http.MethodDelete}
+	}
+	h := Middleware(cfg, methods)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		preflight   bool
+		wantCode    int
+		wantOrigin  string
+		wantMethods string
+	}{
+		{
+			name:        "Answers preflight for allowed origin",
+			method:      http.MethodOptions,
+			path:        "/posts/1",
+			origin:      "https://app.example.com",
+			preflight:   true,
+			wantCode:    http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantMethods: "GET, DELETE",
+		},
+		{
+			name:        "Answers preflight for origin pattern",
+			method:      http.MethodOptions,
+			path:        "/posts/1",
+			origin:      "https://spa.example.org",
+			preflight:   true,
+			wantCode:    http.StatusNoContent,
+			wantOrigin:  "https://spa.example.org",
+			wantMethods: "GET, DELETE",
+		},
+		{
+			name:      "Refuses preflight for other origin",
+			method:    http.MethodOptions,
+			path:      "/posts/1",
+			origin:    "https://example.net",
+			preflight: true,
+			wantCode:  http.StatusNoContent,
+		},
+		{
+			name:      "Passes preflight for unknown path through",
+			method:    http.MethodOptions,
+			path:      "/nope",
+			origin:    "https://app.example.com",
+			preflight: true,
+			wantCode:  http.StatusTeapot,
+		},
+		{
+			name:       "Annotates request from allowed origin",
+			method:     http.MethodGet,
+			path:       "/posts/1",
+			origin:     "https://app.example.com",
+			wantCode:   http.StatusTeapot,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name:     "Serves request from other origin without headers",
+			method:   http.MethodGet,
+			path:     "/posts/1",
+			origin:   "https://example.net",
+			wantCode: http.StatusTeapot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+			}
+
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "disabled", cfg: Config{AllowCredentials: true}},
+		{name: "any origin", cfg: Config{AllowedOrigins: []string{"*"}}},
+		{name: "credentials for patterns", cfg: Config{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}},
+		{name: "credentials for any origin", cfg: Config{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}, wantErr: true},
+		{name: "malformed pattern", cfg: Config{AllowedOrigins: []string{"https://[.example.com"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package health serves the liveness, readiness and build information endpoints probed by orchestrators.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultCheckTimeout bounds each readiness check.
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check reports whether a subsystem is able to serve requests.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of a single readiness check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the readiness response, detailing each registered check.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Version describes the running build.
+type Version struct {
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type check struct {
+	name string
+	fn   Check
+}
+
+// Checker aggregates the readiness checks registered by subsystems.
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a readiness check. The server is ready only while every check passes.
+func (c *Checker) Register(name string, fn Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Run executes all checks concurrently, each bounded by the checker timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	)
+	for _, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := c.run(ctx, chk.fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[chk.name] = res
+			if res.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, fn Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Liveness answers 200 for as long as the process is able to serve HTTP.
+func Liveness(w http.ResponseWriter, _ *http.Request) {
+	write(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Readiness answers 200 when every check passes and 503 otherwise, detailing each check.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	write(w, status, report)
+}
+
+// VersionHandler reports the build information of the running binary.
+func VersionHandler(w http.ResponseWriter, _ *http.Request) {
+	write(w, http.StatusOK, BuildVersion())
+}
+
+// BuildVersion reads the module version, VCS revision and Go version embedded in the binary.
+func BuildVersion() Version {
+	v := Version{Version: "(devel)", GoVersion: runtime.Version()}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	v.Module = info.Main.Path
+	if info.Main.Version != "" {
+		v.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.time":
+			v.Time = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		}
+	}
+	return v
+}
+
+func write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
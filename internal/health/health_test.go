@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		{
+			name:       "Ready without checks",
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{},
+		},
+		{
+			name: "Ready when all checks pass",
+			checks: map[string]Check{
+				"storage": func(_ context.Context) error { return nil },
+				"workers": func(_ context.Context) error { return nil },
+			},
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"storage": StatusOK, "workers": StatusOK},
+		},
+		{
+			name: "Not ready when a check fails",
+			checks: map[string]Check{
+				"storage": func(_ context.Context) error { return nil },
+				"workers": func(_ context.Context) error { return errors.New("stopped") },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"storage": StatusOK, "workers": StatusFail},
+		},
+		{
+			name: "Not ready when a check times out",
+			checks: map[string]Check{
+				"storage": func(ctx context.Context) error {
+					<-ctx.Done()
+					time.Sleep(time.Second)
+					return nil
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"storage": StatusFail},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := NewChecker(20 * time.Millisecond)
+			for name, fn := range tt.checks {
+				c.Register(name, fn)
+			}
+
+			rec := httptest.NewRecorder()
+			c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Readiness() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("decode report: %v", err)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Fatalf("Readiness() checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name].Status; got != want {
+					t.Errorf("Readiness() check %q = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package lifecycle coordinates graceful shutdown: failing readiness, draining in-flight requests and running
+// shutdown hooks in the order they were registered.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config tunes the shutdown sequence.
+type Config struct {
+	// PreStopDelay is the time readiness fails before connections are drained, so that load balancers stop
+	// routing new requests here first.
+	PreStopDelay time.Duration `yaml:"pre_stop_delay"`
+	// DrainTimeout bounds the wait for in-flight requests; remaining connections are closed after it.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	// HookTimeout bounds each shutdown hook.
+	HookTimeout time.Duration `yaml:"hook_timeout"`
+}
+
+// Summary describes a completed shutdown.
+type Summary struct {
+	// InFlight is the number of requests being served when draining began.
+	InFlight int64
+	// Abandoned is the number of requests cut off by closing connections after the drain timeout.
+	Abandoned   int64
+	Forced      bool
+	HooksRun    int
+	HooksFailed int
+	Duration    time.Duration
+}
+
+// Server is an HTTP server to drain, along with the addresses of the listeners it serves, which identify it in
+// logs.
+type Server struct {
+	HTTP  *http.Server
+	Addrs []string
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Lifecycle tracks readiness and in-flight requests and holds the shutdown hooks.
+type Lifecycle struct {
+	ready    atomic.Bool
+	inFlight atomic.Int64
+	mu       sync.Mutex
+	hooks    []hook
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// SetReady records whether the server should receive traffic.
+func (l *Lifecycle) SetReady(ready bool) {
+	l.ready.Store(ready)
+}
+
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// InFlight returns the number of requests currently being served.
+func (l *Lifecycle) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// OnShutdown registers fn to run once all servers stopped. Hooks run in registration order.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = append(l.hooks, hook{name: name, fn: fn})
+}
+
+// Middleware counts the requests in flight.
+func (l *Lifecycle) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.inFlight.Add(1)
+		defer l.inFlight.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Shutdown fails readiness, waits for the pre-stop delay, drains the servers and runs the shutdown hooks.
+func (l *Lifecycle) Shutdown(cfg Config, servers ...Server) Summary {
+	start := time.Now()
+
+	l.SetReady(false)
+	slog.Info("shutting down, readiness failing", slog.Duration("pre_stop_delay", cfg.PreStopDelay))
+	time.Sleep(cfg.PreStopDelay)
+
+	sum := Summary{InFlight: l.InFlight()}
+	slog.Info("draining connections", slog.Int64("in_flight", sum.InFlight), slog.Duration("timeout", cfg.DrainTimeout))
+
+	sum.Abandoned, sum.Forced = l.Drain(cfg.DrainTimeout, servers...)
+
+	l.mu.Lock()
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	for _, h := range hooks {
+		sum.HooksRun++
+		if err := l.runHook(h, cfg.HookTimeout); err != nil {
+			sum.HooksFailed++
+			slog.Error("shutdown hook failed", slog.String("hook", h.name), slog.String("error", err.Error()))
+		}
+	}
+
+	sum.Duration = time.Since(start)
+	slog.Info("shutdown complete",
+		slog.Int64("in_flight", sum.InFlight),
+		slog.Int64("abandoned", sum.Abandoned),
+		slog.Bool("forced", sum.Forced),
+		slog.Int("hooks_run", sum.HooksRun),
+		slog.Int("hooks_failed", sum.HooksFailed),
+		slog.Duration("dur", sum.Duration),
+	)
+
+	return sum
+}
+
+// Drain stops the servers from accepting connections and waits up to timeout for in-flight requests, then closes
+// the connections left. It reports the requests cut off and whether connections had to be closed.
+func (l *Lifecycle) Drain(timeout time.Duration, servers ...Server) (int64, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var forced atomic.Bool
+	for _, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.HTTP.Shutdown(ctx); err != nil {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					slog.Error("server shutdown failed", slog.Any("addrs", srv.Addrs), slog.String("error", err.Error()))
+				}
+				forced.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !forced.Load() {
+		return 0, false
+	}
+	abandoned := l.InFlight()
+	for _, srv := range servers {
+		_ = srv.HTTP.Close()
+	}
+	return abandoned, true
+}
+
+func (l *Lifecycle) runHook(h hook, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.fn(ctx)
+	slog.Debug("shutdown hook finished", slog.String("hook", h.name), slog.Duration("dur", time.Since(start)))
+
+	return err
+}
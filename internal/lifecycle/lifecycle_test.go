@@ -0,0 +1,80 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLifecycle_Shutdown(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		handlerDelay time.Duration
+		drainTimeout time.Duration
+		want         Summary
+	}{
+		{
+			name:         "Drains in-flight request",
+			handlerDelay: 50 * time.Millisecond,
+			drainTimeout: time.Second,
+			want:         Summary{InFlight: 1, HooksRun: 2, HooksFailed: 1},
+		},
+		{
+			name:         "Forces close after drain timeout",
+			handlerDelay: time.Second,
+			drainTimeout: 20 * time.Millisecond,
+			want:         Summary{InFlight: 1, Abandoned: 1, Forced: true, HooksRun: 2, HooksFailed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			l := New()
+			l.SetReady(true)
+
+			started := make(chan struct{})
+			srv := httptest.NewUnstartedServer(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.handlerDelay):
+				case <-r.Context().Done():
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})))
+			srv.Start()
+			defer srv.Close()
+
+			var order []string
+			l.OnShutdown("first", func(context.Context) error {
+				order = append(order, "first")
+				return errors.New("flush failed")
+			})
+			l.OnShutdown("second", func(context.Context) error {
+				order = append(order, "second")
+				return nil
+			})
+
+			go func() { _, _ = http.Get(srv.URL) }() //nolint:noctx // request is cut off by the test on purpose
+			<-started
+
+			got := l.Shutdown(Config{DrainTimeout: tt.drainTimeout, HookTimeout: time.Second},
+				Server{HTTP: srv.Config, Addrs: []string{srv.Listener.Addr().String()}})
+			got.Duration = 0
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Shutdown() = %+v, want %+v", got, tt.want)
+			}
+			if l.Ready() {
+				t.Errorf("Ready() = true after shutdown")
+			}
+			if want := []string{"first", "second"}; !reflect.DeepEqual(order, want) {
+				t.Errorf("hooks ran in order %v, want %v", order, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package listener opens the network listeners the servers accept connections on.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// UnixPrefix marks addresses of unix domain sockets, e.g. unix:/run/rest/admin.sock.
+const UnixPrefix = "unix:"
+
+// Options apply to the unix domain sockets created by Listen.
+type Options struct {
+	// SocketMode sets the permissions of sockets, 0 keeps those left by the umask.
+	SocketMode fs.FileMode
+	// SocketGroup, a group name or ID, owns sockets when set.
+	SocketGroup string
+}
+
+// ParseMode parses an octal permission such as 0660. An empty string is 0.
+func ParseMode(s string) (fs.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q, want octal permissions such as 0660", s)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// Listen listens on a TCP address such as 127.0.0.1:9090, or on a unix domain socket when addr starts with
+// unix:. A socket file left behind by a previous process is removed first.
+func Listen(addr string, opts Options) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, UnixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("listen %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("stat socket: %w", err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.apply(path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// ListenAll listens on every address. When one fails, those already opened are closed.
+func ListenAll(addrs []string, opts Options) ([]net.Listener, error) {
+	out := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := Listen(addr, opts)
+		if err != nil {
+			for _, l := range out {
+				l.Close()
+			}
+			return nil, err
+		}
+		out = append(out, ln)
+	}
+	return out, nil
+}
+
+func (o Options) apply(path string) error {
+	if o.SocketGroup != "" {
+		gid, err := lookupGroup(o.SocketGroup)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return fmt.Errorf("chown socket: %w", err)
+		}
+	}
+	if o.SocketMode != 0 {
+		if err := os.Chmod(path, o.SocketMode); err != nil {
+			return fmt.Errorf("chmod socket: %w", err)
+		}
+	}
+	return nil
+}
+
+func lookupGroup(name string) (int, error) {
+	if gid, err := strconv.Atoi(name); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("lookup socket group: %w", err)
+	}
+	return strconv.Atoi(g.Gid)
+}
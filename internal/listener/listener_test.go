@@ -0,0 +1,58 @@
+package listener
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenAll(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "api.sock")
+	// A regular file at a socket path must not be removed.
+	if err := os.WriteFile(filepath.Join(dir, "plain"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	lns, err := ListenAll([]string{"127.0.0.1:0", UnixPrefix + sock}, Options{SocketMode: 0o660})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, ln := range lns {
+			ln.Close()
+		}
+	}()
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o660 {
+		t.Errorf("socket mode = %v, want 0660", info.Mode().Perm())
+	}
+
+	if _, err := Listen(UnixPrefix+filepath.Join(dir, "plain"), Options{}); err == nil {
+		t.Error("Listen() replaced a regular file")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "0660", want: 0o660},
+		{in: "600", want: 0o600},
+		{in: "0999", wantErr: true},
+		{in: "1777", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMode(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMode(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
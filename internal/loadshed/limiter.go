@@ -0,0 +1,101 @@
+// Package loadshed bounds the number of requests served concurrently, queueing a few and rejecting the rest
+// so that latency stays bounded under overload.
+package loadshed
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Config sizes a Limiter. Writes may only queue while the queue is less than half full, so they are shed
+// before reads.
+type Config struct {
+	MaxInFlight  int           `yaml:"max_in_flight"`
+	MaxQueue     int           `yaml:"max_queue"`
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+}
+
+// Stats is a snapshot of a Limiter's counters.
+type Stats struct {
+	InFlight   int64 `json:"in_flight"`
+	Queued     int64 `json:"queued"`
+	ShedReads  int64 `json:"shed_reads"`
+	ShedWrites int64 `json:"shed_writes"`
+}
+
+type Limiter struct {
+	cfg        Config
+	slots      chan struct{}
+	queued     atomic.Int64
+	shedReads  atomic.Int64
+	shedWrites atomic.Int64
+
+	metrics *metrics
+}
+
+func NewLimiter(cfg Config) *Limiter {
+	l := &Limiter{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxInFlight),
+	}
+	l.metrics = newMetrics(l)
+	return l
+}
+
+// Acquire waits for a free slot and returns the function releasing it. It returns false when the request
+// has to be shed because the queue is full, the wait timed out or ctx was cancelled.
+func (l *Limiter) Acquire(ctx context.Context, write bool) (func(), bool) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, true
+	default:
+	}
+
+	limit := int64(l.cfg.MaxQueue)
+	if write {
+		limit /= 2
+	}
+	if l.queued.Add(1) > limit {
+		l.queued.Add(-1)
+		l.shed(write)
+		return nil, false
+	}
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.shed(write)
+	return nil, false
+}
+
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		InFlight:   int64(len(l.slots)),
+		Queued:     l.queued.Load(),
+		ShedReads:  l.shedReads.Load(),
+		ShedWrites: l.shedWrites.Load(),
+	}
+}
+
+func (l *Limiter) release() {
+	<-l.slots
+}
+
+func (l *Limiter) shed(write bool) {
+	if write {
+		l.shedWrites.Add(1)
+		l.metrics.shed.WithLabelValues("write").Inc()
+		return
+	}
+	l.shedReads.Add(1)
+	l.metrics.shed.WithLabelValues("read").Inc()
+}
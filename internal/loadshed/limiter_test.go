@@ -0,0 +1,99 @@
+package loadshed
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		queued    int64
+		write     bool
+		want      bool
+		wantStats Stats
+	}{
+		{name: "Queues read while queue has room", queued: 3, want: true, wantStats: Stats{InFlight: 1, Queued: 3}},
+		{name: "Sheds read when queue is full", queued: 4, wantStats: Stats{InFlight: 1, Queued: 4, ShedReads: 1}},
+		{name: "Queues write while queue is less than half full", queued: 1, write: true, want: true, wantStats: Stats{InFlight: 1, Queued: 1}},
+		{name: "Sheds write when queue is half full", queued: 2, write: true, wantStats: Stats{InFlight: 1, Queued: 2, ShedWrites: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			l := NewLimiter(Config{MaxInFlight: 1, MaxQueue: 4, QueueTimeout: time.Second})
+			release, ok := l.Acquire(context.Background(), false)
+			if !ok {
+				t.Fatalf("Acquire() of free slot = false")
+			}
+			l.queued.Store(tt.queued)
+
+			// Free the slot shortly after, so that queued requests are served.
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				release()
+			}()
+
+			next, got := l.Acquire(context.Background(), tt.write)
+			if got != tt.want {
+				t.Fatalf("Acquire() = %v, want %v", got, tt.want)
+			}
+			if got := l.Stats(); got != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.wantStats)
+			}
+			if next != nil {
+				next()
+			}
+		})
+	}
+}
+
+func TestLimiter_Acquire_timeout(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(Config{MaxInFlight: 1, MaxQueue: 4, QueueTimeout: 10 * time.Millisecond})
+	if _, ok := l.Acquire(context.Background(), false); !ok {
+		t.Fatalf("Acquire() of free slot = false")
+	}
+
+	if _, ok := l.Acquire(context.Background(), false); ok {
+		t.Errorf("Acquire() = true, want shed after queue timeout")
+	}
+	if got, want := l.Stats(), (Stats{InFlight: 1, ShedReads: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLimiter_Collectors(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(Config{MaxInFlight: 1, MaxQueue: 4, QueueTimeout: 10 * time.Millisecond})
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(l.Collectors()...)
+
+	if _, ok := l.Acquire(context.Background(), false); !ok {
+		t.Fatalf("Acquire() of free slot = false")
+	}
+	if _, ok := l.Acquire(context.Background(), true); ok {
+		t.Fatalf("Acquire() = true, want shed after queue timeout")
+	}
+
+	want := `
+# HELP rest_loadshed_in_flight Requests currently holding a slot.
+# TYPE rest_loadshed_in_flight gauge
+rest_loadshed_in_flight 1
+# HELP rest_loadshed_queue_depth Requests currently waiting for a free slot.
+# TYPE rest_loadshed_queue_depth gauge
+rest_loadshed_queue_depth 0
+# HELP rest_loadshed_shed_total Requests rejected because the server was overloaded, by class: read or write.
+# TYPE rest_loadshed_shed_total counter
+rest_loadshed_shed_total{class="write"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,39 @@
+package loadshed
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	shed     *prometheus.CounterVec
+	queued   prometheus.GaugeFunc
+	inFlight prometheus.GaugeFunc
+}
+
+func newMetrics(l *Limiter) *metrics {
+	return &metrics{
+		shed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "rest",
+			Subsystem: "loadshed",
+			Name:      "shed_total",
+			Help:      "Requests rejected because the server was overloaded, by class: read or write.",
+		}, []string{"class"}),
+		queued: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "rest",
+			Subsystem: "loadshed",
+			Name:      "queue_depth",
+			Help:      "Requests currently waiting for a free slot.",
+		}, func() float64 { return float64(l.queued.Load()) }),
+		inFlight: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "rest",
+			Subsystem: "loadshed",
+			Name:      "in_flight",
+			Help:      "Requests currently holding a slot.",
+		}, func() float64 { return float64(len(l.slots)) }),
+	}
+}
+
+// Collectors returns the metrics of the limiter, to be registered with a Prometheus registry.
+func (l *Limiter) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{l.metrics.shed, l.metrics.queued, l.metrics.inFlight}
+}
@@ -0,0 +1,44 @@
+package loadshed
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// Middleware serves requests within the limits of l and answers the rest with 503 and Retry-After.
+// Requests for the exempt paths, such as health probes, are never limited.
+func Middleware(l *Limiter, retryAfter time.Duration, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			release, ok := l.Acquire(r.Context(), isWrite(r))
+			if !ok {
+				slog.DebugContext(r.Context(), "request shed", slog.String("method", r.Method), slog.Any("stats", l.Stats()))
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				problem.Error(w, r, http.StatusServiceUnavailable, "server is overloaded")
+				return
+			}
+			defer release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/phsym/console-slog"
+
+	"github.com/jqdurham/rest-sample/internal/redact"
+)
+
+// Formats supported by NewHandler.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+	FormatLogfmt  = "logfmt"
+)
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+// Options configure the handler created by NewHandler.
+type Options struct {
+	// Format is one of console, json or logfmt.
+	Format string
+	// Level holds the minimum level logged; it may be changed while the server runs.
+	Level *slog.LevelVar
+	// NoColor disables colors of the console format, e.g. when writing to a file.
+	NoColor bool
+	// Redactor, if set, masks personal data in every record.
+	Redactor *redact.Redactor
+}
+
+// NewHandler creates the handler writing records to w. Source locations are only included while the level is
+// Debug, so that they appear as soon as debugging is switched on at runtime.
+func NewHandler(w io.Writer, opts Options) (slog.Handler, error) {
+	var h slog.Handler
+	switch opts.Format {
+	case FormatConsole:
+		h = console.NewHandler(w, &console.HandlerOptions{Level: opts.Level, AddSource: true, NoColor: opts.NoColor})
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: opts.Level, AddSource: true})
+	case FormatLogfmt:
+		h = slog.NewTextHandler(w, &slog.HandlerOptions{Level: opts.Level, AddSource: true})
+	default:
+		return nil, &InvalidError{message: fmt.Sprintf("unknown log format %q", opts.Format)}
+	}
+
+	h = &sourceHandler{Handler: h, level: opts.Level}
+	if opts.Redactor != nil {
+		h = NewRedactHandler(h, opts.Redactor)
+	}
+	return NewContextHandler(h), nil
+}
+
+// ParseLevel parses a level name such as debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, &InvalidError{message: fmt.Sprintf("unknown log level %q", s)}
+	}
+	return l, nil
+}
+
+// ToggleDebug switches level between Debug and the configured base level whenever a signal is received, until ctx
+// is done.
+func ToggleDebug(ctx context.Context, signals <-chan os.Signal, level, base *slog.LevelVar) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			next := slog.LevelDebug
+			if level.Level() == slog.LevelDebug {
+				next = base.Level()
+			}
+			level.Set(next)
+			slog.Log(ctx, slog.LevelWarn, "log level changed", slog.String("level", next.String()))
+		}
+	}
+}
+
+// sourceHandler drops the source location of records unless level is Debug.
+type sourceHandler struct {
+	slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *sourceHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if h.level.Level() > slog.LevelDebug {
+		rec.PC = 0
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h *sourceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sourceHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *sourceHandler) WithGroup(name string) slog.Handler {
+	return &sourceHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewHandler_source(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		format     string
+		level      slog.Level
+		wantSource bool
+	}{
+		{name: "JSON at debug includes source", format: FormatJSON, level: slog.LevelDebug, wantSource: true},
+		{name: "JSON at info omits source", format: FormatJSON, level: slog.LevelInfo},
+		{name: "logfmt at debug includes source", format: FormatLogfmt, level: slog.LevelDebug, wantSource: true},
+		{name: "logfmt at warn omits source", format: FormatLogfmt, level: slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			level := new(slog.LevelVar)
+			level.Set(tt.level)
+			h, err := NewHandler(&buf, Options{Format: tt.format, Level: level})
+			if err != nil {
+				t.Fatalf("NewHandler() error = %v", err)
+			}
+
+			slog.New(h).Error("boom")
+
+			if got := strings.Contains(buf.String(), "logging_test.go"); got != tt.wantSource {
+				t.Errorf("source logged = %v, want %v: %s", got, tt.wantSource, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/jqdurham/rest-sample/internal/redact"
+)
+
+// ipKeys are the attribute keys holding client addresses.
+var ipKeys = map[string]bool{"ip": true, "remote_addr": true, "client_ip": true}
+
+// RedactHandler masks personal data in the message and attributes of each record before passing it on, so that
+// every logger benefits from it. Structs and maps are redacted through their JSON representation.
+type RedactHandler struct {
+	slog.Handler
+	r *redact.Redactor
+}
+
+func NewRedactHandler(h slog.Handler, r *redact.Redactor) *RedactHandler {
+	return &RedactHandler{Handler: h, r: r}
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, h.r.Text(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.attr(a)
+	}
+	return &RedactHandler{Handler: h.Handler.WithAttrs(redacted), r: h.r}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{Handler: h.Handler.WithGroup(name), r: h.r}
+}
+
+func (h *RedactHandler) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := strings.ToLower(a.Key)
+	switch {
+	case h.r.Field(key):
+		return slog.String(a.Key, redact.Mask)
+	case ipKeys[key] && a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, h.r.IP(a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if key == "url" || key == "uri" {
+			return slog.String(a.Key, h.r.URL(a.Value.String()))
+		}
+		return slog.String(a.Key, h.r.Text(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]any, len(group))
+		for i, g := range group {
+			redacted[i] = h.attr(g)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		return slog.Any(a.Key, h.value(a.Value.Any()))
+	default:
+		return a
+	}
+}
+
+func (h *RedactHandler) value(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return h.r.Text(v.Error())
+	case http.Header:
+		return h.r.Header(v)
+	case json.RawMessage:
+		return h.json(v)
+	case []byte:
+		return h.r.Text(string(v))
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return h.r.Text(slog.AnyValue(v).String())
+	}
+	return h.json(data)
+}
+
+func (h *RedactHandler) json(data []byte) any {
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return h.r.Text(string(data))
+	}
+	return h.r.Value(decoded)
+}
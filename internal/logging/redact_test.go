@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jqdurham/rest-sample/internal/redact"
+	"github.com/jqdurham/rest-sample/internal/user"
+)
+
+// TestRedactHandler_userEmail proves that the email of a user never reaches the logs verbatim, however it is
+// logged.
+func TestRedactHandler_userEmail(t *testing.T) {
+	t.Parallel()
+	const email = "jane.doe+test@example.com"
+	usr := user.User{ID: 1, Name: "Jane", Email: email, Role: user.RoleViewer}
+	body, _ := json.Marshal(map[string]any{"name": "Jane", "email": email})
+
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+	}{
+		{name: "User struct", log: func(l *slog.Logger) { l.Info("created", slog.Any("user", usr)) }},
+		{name: "User pointer", log: func(l *slog.Logger) { l.Info("created", slog.Any("user", &usr)) }},
+		{name: "Users in a group", log: func(l *slog.Logger) { l.Info("listed", slog.Group("result", slog.Any("users", []user.User{usr}))) }},
+		{name: "Email attribute", log: func(l *slog.Logger) { l.Info("login", slog.String("email", usr.Email)) }},
+		{name: "Email in message", log: func(l *slog.Logger) { l.Warn(fmt.Sprintf("login failed for %s", usr.Email)) }},
+		{name: "Email in error", log: func(l *slog.Logger) { l.Error("failed", slog.Any("error", errors.New("duplicate "+usr.Email))) }},
+		{name: "Email in URL", log: func(l *slog.Logger) { l.Info("request handled", slog.String("url", "/users?email="+usr.Email)) }},
+		{name: "JSON body", log: func(l *slog.Logger) { l.Debug("body", slog.Any("body", json.RawMessage(body))) }},
+		{name: "Logger attributes", log: func(l *slog.Logger) { l.With(slog.Any("user", usr)).Info("created") }},
+	}
+	for _, format := range []string{FormatJSON, FormatLogfmt, FormatConsole} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+				r, err := redact.New(redact.DefaultConfig)
+				if err != nil {
+					t.Fatalf("redact.New() error = %v", err)
+				}
+				var buf bytes.Buffer
+				level := new(slog.LevelVar)
+				level.Set(slog.LevelDebug)
+				h, err := NewHandler(&buf, Options{Format: format, Level: level, NoColor: true, Redactor: r})
+				if err != nil {
+					t.Fatalf("NewHandler() error = %v", err)
+				}
+
+				tt.log(slog.New(h))
+
+				out := buf.String()
+				if out == "" {
+					t.Fatal("nothing was logged")
+				}
+				for _, leak := range []string{email, "jane.doe", strings.ReplaceAll(email, "@", "%40")} {
+					if strings.Contains(out, leak) {
+						t.Errorf("log contains %q: %s", leak, out)
+					}
+				}
+			})
+		}
+	}
+}
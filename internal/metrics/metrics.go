@@ -0,0 +1,109 @@
+// Package metrics exposes Prometheus metrics for the HTTP server. Requests are labeled by the OpenAPI
+// operationId rather than the URL, so that path parameters cannot blow up label cardinality.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/jqdurham/rest-sample/internal/operation"
+)
+
+// Namespace prefixes the names of all metrics of the application.
+const Namespace = "rest"
+
+// Unmatched labels requests that match no operation of the OpenAPI document, such as probes and preflights.
+const Unmatched = "unmatched"
+
+// NewRegistry creates a registry holding the Go runtime and process collectors.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Handler serves the metrics gathered by reg in the Prometheus text format.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg})
+}
+
+// HTTP holds the per-operation request metrics.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewHTTP creates the request metrics and registers them with reg.
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Requests served, by operation, method and status code.",
+		}, []string{"operation", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to serve requests, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "method"}),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "response_size_bytes",
+			Help:      "Size of response bodies, by operation.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"operation"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Requests currently being served, by operation.",
+		}, []string{"operation"}),
+	}
+	reg.MustRegister(m.requests, m.duration, m.size, m.inFlight)
+	return m
+}
+
+// Middleware records the request metrics. It must run after the operation has been resolved.
+func (m *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := operation.FromContext(r.Context())
+		if op == "" {
+			op = Unmatched
+		}
+
+		inFlight := m.inFlight.WithLabelValues(op)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		stats := httpsnoop.CaptureMetrics(next, w, r)
+
+		method := methodLabel(r.Method)
+		m.requests.WithLabelValues(op, method, strconv.Itoa(stats.Code)).Inc()
+		m.duration.WithLabelValues(op, method).Observe(stats.Duration.Seconds())
+		m.size.WithLabelValues(op).Observe(float64(stats.Written))
+	})
+}
+
+// methodLabel bounds the method label to the standard methods, as clients may send arbitrary ones.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
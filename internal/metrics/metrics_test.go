@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jqdurham/rest-sample/internal/operation"
+)
+
+func TestHTTP_Middleware(t *testing.T) {
+	t.Parallel()
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "test", "version": "1"},
+		"paths": {"/posts/{id}": {"get": {
+			"operationId": "getPost",
+			"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+			"responses": {"200": {"description": "ok"}}
+		}}}
+	}`))
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	resolve, err := operation.Middleware(swagger)
+	if err != nil {
+		t.Fatalf("operation.Middleware() error = %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	h := resolve(NewHTTP(reg).Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})))
+	for _, target := range []string{"/posts/1", "/posts/2", "/healthz"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	want := `
+# HELP rest_http_requests_total Requests served, by operation, method and status code.
+# TYPE rest_http_requests_total counter
+rest_http_requests_total{code="200",method="GET",operation="getPost"} 2
+rest_http_requests_total{code="200",method="GET",operation="unmatched"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "rest_http_requests_total"); err != nil {
+		t.Errorf("requests_total mismatch: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package operation resolves the OpenAPI operation a request is addressed to, so that middleware can make
+// per-operation decisions without depending on raw URLs. Operations are identified by the operationId of the
+// embedded spec, which oapi-codegen normalises to the method names of oapi.ServerInterface, e.g. CreatePost.
+package operation
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+type ctxKey struct{}
+
+// Middleware stores the operationId of the OpenAPI operation matching each request in its context.
+// Requests that match no operation are passed through without one.
+func Middleware(swagger *openapi3.T) (func(http.Handler) http.Handler, error) {
+	router, err := gorillamux.NewRouter(swagger)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := find(router, r); id != "" {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, id))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// FromContext returns the operationId resolved for the request, if any.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// IDs lists the operationIds declared by the OpenAPI document.
+func IDs(swagger *openapi3.T) []string {
+	var ids []string
+	for _, item := range swagger.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.OperationID != "" {
+				ids = append(ids, op.OperationID)
+			}
+		}
+	}
+	return ids
+}
+
+// Methods returns a function listing the HTTP methods the OpenAPI document defines for the path of a
+// request, whatever the request's own method.
+func Methods(swagger *openapi3.T) (func(r *http.Request) []string, error) {
+	router, err := gorillamux.NewRouter(swagger)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *http.Request) []string {
+		var out []string
+		for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			probe := r.WithContext(r.Context())
+			probe.Method = m
+			if _, _, err := router.FindRoute(probe); err == nil {
+				out = append(out, m)
+			}
+		}
+		return out
+	}, nil
+}
+
+func find(router routers.Router, r *http.Request) string {
+	route, _, err := router.FindRoute(r)
+	if err != nil || route.Operation == nil {
+		return ""
+	}
+	return route.Operation.OperationID
+}
@@ -0,0 +1,18 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+func missingOperationsError(ids []string) *InvalidError {
+	return &InvalidError{message: fmt.Sprintf("policy has no entry for operations: %s", strings.Join(ids, ", "))}
+}
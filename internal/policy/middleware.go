@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+type ctxKey int
+
+const (
+	decisionKey ctxKey = iota
+	rolesKey
+)
+
+// RoleResolver returns the roles recorded for a local user.
+type RoleResolver func(ctx context.Context, userID int64) []string
+
+// Middleware denies requests for operations the caller's roles do not permit. It must run after the
+// operation has been resolved and the caller authenticated.
+func Middleware(e *Enforcer, resolve RoleResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			opID := operation.FromContext(r.Context())
+			if opID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, authenticated := auth.IdentityFromContext(r.Context())
+			roles := Roles(r.Context(), id, resolve)
+
+			decision := e.Decide(roles, opID)
+			if !decision.Allowed {
+				slog.DebugContext(r.Context(), "operation denied by policy", slog.String("operation", opID), slog.Any("roles", roles))
+				if !authenticated {
+					problem.Error(w, r, http.StatusUnauthorized, "authentication required for "+opID)
+					return
+				}
+				problem.Error(w, r, http.StatusForbidden, "roles do not permit "+opID)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), decisionKey, decision)
+			ctx = context.WithValue(ctx, rolesKey, roles)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Roles returns the effective roles of a caller: those asserted by its credentials plus those recorded for
+// its local user. Unauthenticated callers hold only RoleAnonymous.
+func Roles(ctx context.Context, id *auth.Identity, resolve RoleResolver) []string {
+	if id == nil {
+		return []string{RoleAnonymous}
+	}
+
+	roles := append([]string{}, id.Roles...)
+	if id.UserID > 0 && resolve != nil {
+		roles = append(roles, resolve(ctx, id.UserID)...)
+	}
+
+	return roles
+}
+
+// AnyResource reports whether the policy let the caller perform the operation on resources of other users, that
+// is granted it without the ":own" suffix. It is false for requests the policy did not evaluate.
+func AnyResource(ctx context.Context) bool {
+	d, ok := ctx.Value(decisionKey).(Decision)
+	return ok && d.Allowed && !d.OwnOnly
+}
+
+// RolesFromContext returns the effective roles the request was authorized with.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return roles
+}
@@ -0,0 +1,151 @@
+// Package policy decides which OpenAPI operations a caller may perform based on their roles.
+//
+// A policy grants each role a set of permissions and maps each operation, named as in oapi.ServerInterface,
+// to the permissions it needs; holding any one of them is enough. A permission suffixed with ":own" grants the
+// base permission for resources owned by the caller only, leaving the ownership check to the handler.
+// Operations mapped to no permissions are open to every caller the OpenAPI security requirements let through.
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+// RoleAnonymous is the role of callers that did not authenticate.
+const RoleAnonymous = "anonymous"
+
+const ownSuffix = ":own"
+
+//go:embed default.json
+var defaultPolicy []byte
+
+// Policy is the document loaded from a policy file.
+type Policy struct {
+	Roles      map[string][]string `json:"roles"`
+	Operations map[string][]string `json:"operations"`
+}
+
+// Decision is the outcome of evaluating an operation against a caller's roles.
+type Decision struct {
+	Allowed bool
+	// OwnOnly is set when the caller may only perform the operation on resources they own.
+	OwnOnly bool
+}
+
+// Enforcer evaluates requests against the active policy, which may be replaced at runtime.
+type Enforcer struct {
+	policy atomic.Pointer[Policy]
+}
+
+// Default returns the policy compiled into the binary.
+func Default() (*Policy, error) {
+	return parse(defaultPolicy)
+}
+
+// Load reads a policy from a JSON file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	if p.Roles == nil || p.Operations == nil {
+		return nil, &InvalidError{message: "policy must define roles and operations"}
+	}
+	return &p, nil
+}
+
+// Validate confirms the policy has an entry for every operation, so that none is denied by omission.
+func (p *Policy) Validate(operationIDs []string) error {
+	var missing []string
+	for _, id := range operationIDs {
+		if _, ok := p.Operations[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return missingOperationsError(missing)
+	}
+	return nil
+}
+
+func NewEnforcer(p *Policy) *Enforcer {
+	e := &Enforcer{}
+	e.policy.Store(p)
+	return e
+}
+
+// Swap atomically replaces the active policy.
+func (e *Enforcer) Swap(p *Policy) {
+	e.policy.Store(p)
+}
+
+// Decide evaluates whether callers holding roles may perform the operation. Unknown operations are denied.
+func (e *Enforcer) Decide(roles []string, operationID string) Decision {
+	return e.policy.Load().decide(roles, operationID)
+}
+
+func (p *Policy) decide(roles []string, operationID string) Decision {
+	required, ok := p.Operations[operationID]
+	if !ok {
+		return Decision{}
+	}
+	if len(required) == 0 {
+		return Decision{Allowed: true}
+	}
+
+	granted := p.permissions(roles)
+	out := Decision{}
+	for _, perm := range required {
+		if slices.Contains(granted, perm) {
+			return Decision{Allowed: true}
+		}
+		if slices.Contains(granted, perm+ownSuffix) {
+			out = Decision{Allowed: true, OwnOnly: true}
+		}
+	}
+
+	return out
+}
+
+// Permissions lists the permissions granted to roles.
+func (e *Enforcer) Permissions(roles []string) []string {
+	return e.policy.Load().permissions(roles)
+}
+
+// AllowedOperations lists the operations callers holding roles may perform, at least on their own resources.
+func (e *Enforcer) AllowedOperations(roles []string) []string {
+	p := e.policy.Load()
+
+	var out []string
+	for id := range p.Operations {
+		if p.decide(roles, id).Allowed {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func (p *Policy) permissions(roles []string) []string {
+	var out []string
+	for _, role := range roles {
+		out = append(out, p.Roles[role]...)
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestEnforcer_Decide(t *testing.T) {
+	t.Parallel()
+	p, err := Default()
+	if err != nil {
+		t.Fatalf("Default() error = %v", err)
+	}
+	e := NewEnforcer(p)
+
+	tests := []struct {
+		name      string
+		roles     []string
+		operation string
+		want      Decision
+	}{
+		{name: "Admin may delete posts", roles: []string{"admin"}, operation: "DeletePost", want: Decision{Allowed: true}},
+		{name: "Editor may delete own posts", roles: []string{"editor"}, operation: "DeletePost", want: Decision{Allowed: true, OwnOnly: true}},
+		{
+			name:      "Editor with admin role is unrestricted",
+			roles:     []string{"editor", "admin"},
+			operation: "DeletePost",
+			want:      Decision{Allowed: true},
+		},
+		{name: "Viewer may not delete posts", roles: []string{"viewer"}, operation: "DeletePost"},
+		{name: "Anonymous may list posts", roles: []string{RoleAnonymous}, operation: "ListPosts", want: Decision{Allowed: true}},
+		{name: "Anonymous may log in", roles: []string{RoleAnonymous}, operation: "CreateSession", want: Decision{Allowed: true}},
+		{name: "Unknown operation is denied", roles: []string{"admin"}, operation: "DropDatabase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := e.Decide(tt.roles, tt.operation); got != tt.want {
+				t.Errorf("Decide() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package post
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type NotFoundError struct {
 	id int64
@@ -12,8 +15,32 @@ func (e NotFoundError) Error() string {
 
 type InvalidError struct {
 	message string
+	// reason names the failed validation rule for metrics; empty outside of isValidPost.
+	reason string
 }
 
 func (e InvalidError) Error() string {
 	return e.message
 }
+
+type ForbiddenError struct {
+	message string
+}
+
+func (e ForbiddenError) Error() string {
+	return e.message
+}
+
+type QuotaError struct {
+	message    string
+	retryAfter time.Duration
+}
+
+func (e QuotaError) Error() string {
+	return e.message
+}
+
+// RetryAfter reports when the quota allows another post, zero if only deleting posts frees it.
+func (e QuotaError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
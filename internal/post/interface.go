@@ -1,10 +1,14 @@
 package post
 
+import "context"
+
 //go:generate mockery --name=Servicer
 type Servicer interface {
-	ListPosts() []Post
-	GetPost(id int64) (*Post, error)
-	CreatePost(pst *Post) (*Post, error)
-	UpdatePost(id int64, pst *Post) (*Post, error)
-	DeletePost(id int64) error
+	ListPosts(ctx context.Context) []Post
+	GetPost(ctx context.Context, id int64) (*Post, error)
+	CreatePost(ctx context.Context, actor Actor, pst *Post) (*Post, error)
+	UpdatePost(ctx context.Context, actor Actor, id int64, pst *Post) (*Post, error)
+	TransferPost(ctx context.Context, actor Actor, id, userID int64) (*Post, error)
+	DeletePost(ctx context.Context, actor Actor, id int64) error
+	GetUsage(ctx context.Context, userID int64) Usage
 }
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	post "github.com/jqdurham/rest-sample/internal/post"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,9 +14,9 @@ type Servicer struct {
 	mock.Mock
 }
 
-// CreatePost provides a mock function with given fields: pst
-func (_m *Servicer) CreatePost(pst *post.Post) (*post.Post, error) {
-	ret := _m.Called(pst)
+// CreatePost provides a mock function with given fields: ctx, actor, pst
+func (_m *Servicer) CreatePost(ctx context.Context, actor post.Actor, pst *post.Post) (*post.Post, error) {
+	ret := _m.Called(ctx, actor, pst)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreatePost")
@@ -22,19 +24,19 @@ func (_m *Servicer) CreatePost(pst *post.Post) (*post.Post, error) {
 
 	var r0 *post.Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*post.Post) (*post.Post, error)); ok {
-		return rf(pst)
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, *post.Post) (*post.Post, error)); ok {
+		return rf(ctx, actor, pst)
 	}
-	if rf, ok := ret.Get(0).(func(*post.Post) *post.Post); ok {
-		r0 = rf(pst)
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, *post.Post) *post.Post); ok {
+		r0 = rf(ctx, actor, pst)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*post.Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*post.Post) error); ok {
-		r1 = rf(pst)
+	if rf, ok := ret.Get(1).(func(context.Context, post.Actor, *post.Post) error); ok {
+		r1 = rf(ctx, actor, pst)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,17 +44,17 @@ func (_m *Servicer) CreatePost(pst *post.Post) (*post.Post, error) {
 	return r0, r1
 }
 
-// DeletePost provides a mock function with given fields: id
-func (_m *Servicer) DeletePost(id int64) error {
-	ret := _m.Called(id)
+// DeletePost provides a mock function with given fields: ctx, actor, id
+func (_m *Servicer) DeletePost(ctx context.Context, actor post.Actor, id int64) error {
+	ret := _m.Called(ctx, actor, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeletePost")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, int64) error); ok {
+		r0 = rf(ctx, actor, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,9 +62,9 @@ func (_m *Servicer) DeletePost(id int64) error {
 	return r0
 }
 
-// GetPost provides a mock function with given fields: id
-func (_m *Servicer) GetPost(id int64) (*post.Post, error) {
-	ret := _m.Called(id)
+// GetPost provides a mock function with given fields: ctx, id
+func (_m *Servicer) GetPost(ctx context.Context, id int64) (*post.Post, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetPost")
@@ -70,19 +72,19 @@ func (_m *Servicer) GetPost(id int64) (*post.Post, error) {
 
 	var r0 *post.Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (*post.Post, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*post.Post, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int64) *post.Post); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *post.Post); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*post.Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,17 +92,35 @@ func (_m *Servicer) GetPost(id int64) (*post.Post, error) {
 	return r0, r1
 }
 
-// ListPosts provides a mock function with given fields:
-func (_m *Servicer) ListPosts() []post.Post {
-	ret := _m.Called()
+// GetUsage provides a mock function with given fields: ctx, userID
+func (_m *Servicer) GetUsage(ctx context.Context, userID int64) post.Usage {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsage")
+	}
+
+	var r0 post.Usage
+	if rf, ok := ret.Get(0).(func(context.Context, int64) post.Usage); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(post.Usage)
+	}
+
+	return r0
+}
+
+// ListPosts provides a mock function with given fields: ctx
+func (_m *Servicer) ListPosts(ctx context.Context) []post.Post {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListPosts")
 	}
 
 	var r0 []post.Post
-	if rf, ok := ret.Get(0).(func() []post.Post); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []post.Post); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]post.Post)
@@ -110,9 +130,39 @@ func (_m *Servicer) ListPosts() []post.Post {
 	return r0
 }
 
-// UpdatePost provides a mock function with given fields: id, pst
-func (_m *Servicer) UpdatePost(id int64, pst *post.Post) (*post.Post, error) {
-	ret := _m.Called(id, pst)
+// TransferPost provides a mock function with given fields: ctx, actor, id, userID
+func (_m *Servicer) TransferPost(ctx context.Context, actor post.Actor, id int64, userID int64) (*post.Post, error) {
+	ret := _m.Called(ctx, actor, id, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransferPost")
+	}
+
+	var r0 *post.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, int64, int64) (*post.Post, error)); ok {
+		return rf(ctx, actor, id, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, int64, int64) *post.Post); ok {
+		r0 = rf(ctx, actor, id, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*post.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, post.Actor, int64, int64) error); ok {
+		r1 = rf(ctx, actor, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePost provides a mock function with given fields: ctx, actor, id, pst
+func (_m *Servicer) UpdatePost(ctx context.Context, actor post.Actor, id int64, pst *post.Post) (*post.Post, error) {
+	ret := _m.Called(ctx, actor, id, pst)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdatePost")
@@ -120,19 +170,19 @@ func (_m *Servicer) UpdatePost(id int64, pst *post.Post) (*post.Post, error) {
 
 	var r0 *post.Post
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, *post.Post) (*post.Post, error)); ok {
-		return rf(id, pst)
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, int64, *post.Post) (*post.Post, error)); ok {
+		return rf(ctx, actor, id, pst)
 	}
-	if rf, ok := ret.Get(0).(func(int64, *post.Post) *post.Post); ok {
-		r0 = rf(id, pst)
+	if rf, ok := ret.Get(0).(func(context.Context, post.Actor, int64, *post.Post) *post.Post); ok {
+		r0 = rf(ctx, actor, id, pst)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*post.Post)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, *post.Post) error); ok {
-		r1 = rf(id, pst)
+	if rf, ok := ret.Get(1).(func(context.Context, post.Actor, int64, *post.Post) error); ok {
+		r1 = rf(ctx, actor, id, pst)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,8 +1,46 @@
 package post
 
+import "time"
+
 type Post struct {
 	ID      int64
 	Title   string
 	Content string
 	UserID  int64
 }
+
+// Actor is the user on whose behalf posts are created or changed.
+type Actor struct {
+	UserID int64
+	// Admin allows acting on posts authored by any user.
+	Admin bool
+}
+
+func (a Actor) owns(pst Post) bool {
+	return a.Admin || (a.UserID > 0 && a.UserID == pst.UserID)
+}
+
+// Quota limits the posts of each user. Zero limits are not enforced.
+type Quota struct {
+	// WindowLimit is the number of posts a user may create within any sliding Window.
+	WindowLimit int           `yaml:"window_limit"`
+	Window      time.Duration `yaml:"window"`
+	// MaxPosts is the number of posts a user may author in total.
+	MaxPosts int `yaml:"max_posts"`
+}
+
+// Usage reports how much of their quota a user has consumed.
+type Usage struct {
+	Quota
+	// Recent is the number of posts created within the current window.
+	Recent int
+	Posts  int
+	// ResetAt is when the oldest post in the window leaves it, nil when the window is empty.
+	ResetAt *time.Time
+}
+
+// Stats summarizes the stored posts.
+type Stats struct {
+	Posts   int `json:"posts"`
+	Authors int `json:"authors"`
+}
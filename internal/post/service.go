@@ -2,36 +2,68 @@ package post
 
 import (
 	"cmp"
+	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
+	"github.com/jqdurham/rest-sample/internal/tracing"
 	"github.com/jqdurham/rest-sample/internal/user"
 )
 
 // compile time check to make sure Servicer interface is satisfied.
 var _ Servicer = &Service{}
 
+// DefaultQuota allows 50 posts per user per day and 1000 in total.
+var DefaultQuota = Quota{WindowLimit: 50, Window: 24 * time.Hour, MaxPosts: 1000}
+
 type Service struct {
 	mu      sync.RWMutex
 	cache   map[int64]Post
 	lastID  atomic.Int64
 	userSvc user.Servicer
+	quota   Quota
+	// created records when each user's posts within the quota window were created, oldest first.
+	created map[int64][]time.Time
+
+	metrics *metrics
 }
 
-func NewService(userSvc user.Servicer) *Service {
-	return &Service{
+func NewService(userSvc user.Servicer, quota Quota) *Service {
+	svc := &Service{
 		cache:   make(map[int64]Post),
 		userSvc: userSvc,
+		quota:   quota,
+		created: make(map[int64][]time.Time),
+	}
+	svc.metrics = newMetrics(svc)
+	return svc
+}
+
+// Stats summarizes the stored posts.
+func (svc *Service) Stats() Stats {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	authors := make(map[int64]struct{})
+	for _, p := range svc.cache {
+		authors[p.UserID] = struct{}{}
 	}
+	return Stats{Posts: len(svc.cache), Authors: len(authors)}
 }
 
-func (svc *Service) ListPosts() []Post {
+func (svc *Service) ListPosts(ctx context.Context) []Post {
+	_, span := tracing.Start(ctx, "post.ListPosts", tracing.PhaseStorage)
+	defer span.End()
+
 	out := make([]Post, 0, len(svc.cache))
 
 	svc.mu.RLock()
@@ -48,7 +80,10 @@ func (svc *Service) ListPosts() []Post {
 	return out
 }
 
-func (svc *Service) GetPost(id int64) (*Post, error) {
+func (svc *Service) GetPost(ctx context.Context, id int64) (*Post, error) {
+	_, span := tracing.Start(ctx, "post.GetPost", tracing.PhaseStorage)
+	defer span.End()
+
 	svc.mu.RLock()
 	defer svc.mu.RUnlock()
 
@@ -60,69 +95,237 @@ func (svc *Service) GetPost(id int64) (*Post, error) {
 	return &out, nil
 }
 
-func (svc *Service) CreatePost(post *Post) (*Post, error) {
-	if err := svc.isValidPost(post); err != nil {
+// CreatePost stores a new post authored by actor unless another author is given, which only admins may do.
+func (svc *Service) CreatePost(ctx context.Context, actor Actor, post *Post) (*Post, error) {
+	ctx, span := tracing.Start(ctx, "post.CreatePost", tracing.PhaseStorage)
+	defer span.End()
+
+	if post != nil && post.UserID == 0 {
+		post.UserID = actor.UserID
+	}
+
+	if err := svc.isValidPost(ctx, post); err != nil {
 		return nil, err
 	}
 
-	id := svc.lastID.Add(1)
-	post.ID = id
+	if !actor.owns(*post) {
+		return nil, &ForbiddenError{message: "posts may only be created for yourself"}
+	}
 
 	svc.mu.Lock()
+	now := time.Now()
+	if err := svc.checkQuota(post.UserID, now); err != nil {
+		svc.mu.Unlock()
+		return nil, err
+	}
+
+	id := svc.lastID.Add(1)
+	post.ID = id
 	svc.cache[id] = *post
+	if svc.windowed() {
+		svc.created[post.UserID] = append(svc.created[post.UserID], now)
+	}
 	svc.mu.Unlock()
 
-	return svc.GetPost(post.ID)
+	return svc.GetPost(ctx, post.ID)
 }
 
-func (svc *Service) UpdatePost(id int64, post *Post) (*Post, error) {
-	if err := svc.isValidPost(post); err != nil {
-		return nil, err
+// UpdatePost replaces the title and content of a post. The author is kept; see TransferPost.
+func (svc *Service) UpdatePost(ctx context.Context, actor Actor, id int64, post *Post) (*Post, error) {
+	ctx, span := tracing.Start(ctx, "post.UpdatePost", tracing.PhaseStorage)
+	defer span.End()
+
+	if post == nil {
+		return nil, &InvalidError{message: "post missing"}
 	}
 
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
-	if _, ok := svc.cache[id]; !ok {
+	existing, ok := svc.cache[id]
+	if !ok {
 		return nil, &NotFoundError{id: id}
 	}
 
+	if !actor.owns(existing) {
+		return nil, &ForbiddenError{message: "posts of other users may not be updated"}
+	}
+
+	if post.UserID == 0 {
+		post.UserID = existing.UserID
+	}
+	if post.UserID != existing.UserID {
+		return nil, &InvalidError{message: "the author of a post may only be changed by transferring it"}
+	}
+
+	if err := svc.isValidPost(ctx, post); err != nil {
+		return nil, err
+	}
+
 	post.ID = id
 	svc.cache[id] = *post
 
 	return post, nil
 }
 
-func (svc *Service) DeletePost(id int64) error {
+// TransferPost makes userID the author of a post.
+func (svc *Service) TransferPost(ctx context.Context, actor Actor, id, userID int64) (*Post, error) {
+	ctx, span := tracing.Start(ctx, "post.TransferPost", tracing.PhaseStorage)
+	defer span.End()
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	post, ok := svc.cache[id]
+	if !ok {
+		return nil, &NotFoundError{id: id}
+	}
+
+	if !actor.owns(post) {
+		return nil, &ForbiddenError{message: "posts of other users may not be transferred"}
+	}
+
+	if post.UserID == userID {
+		return &post, nil
+	}
+
+	post.UserID = userID
+	if err := svc.isValidPost(ctx, &post); err != nil {
+		return nil, err
+	}
+
+	if svc.quota.MaxPosts > 0 && svc.countPosts(userID) >= svc.quota.MaxPosts {
+		return nil, &QuotaError{message: "new author has reached the maximum number of posts"}
+	}
+
+	svc.cache[id] = post
+
+	return &post, nil
+}
+
+func (svc *Service) DeletePost(ctx context.Context, actor Actor, id int64) error {
+	_, span := tracing.Start(ctx, "post.DeletePost", tracing.PhaseStorage)
+	defer span.End()
+
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
-	if _, ok := svc.cache[id]; !ok {
+	post, ok := svc.cache[id]
+	if !ok {
 		return &NotFoundError{id: id}
 	}
 
+	if !actor.owns(post) {
+		return &ForbiddenError{message: "posts of other users may not be deleted"}
+	}
+
 	delete(svc.cache, id)
 
 	return nil
 }
 
-func (svc *Service) isValidPost(post *Post) error {
+// SetQuota replaces the post quota. Creation times are only recorded while a quota window is configured, so a
+// window enabled later counts posts from then on.
+func (svc *Service) SetQuota(quota Quota) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.quota = quota
+}
+
+// GetUsage reports how much of the post quota a user has consumed.
+func (svc *Service) GetUsage(ctx context.Context, userID int64) Usage {
+	_, span := tracing.Start(ctx, "post.GetUsage", tracing.PhaseStorage)
+	defer span.End()
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	out := Usage{
+		Quota: svc.quota,
+		Posts: svc.countPosts(userID),
+	}
+	if recent := svc.recent(userID, time.Now()); len(recent) > 0 {
+		out.Recent = len(recent)
+		resetAt := recent[0].Add(svc.quota.Window)
+		out.ResetAt = &resetAt
+	}
+
+	return out
+}
+
+// checkQuota reports whether userID may create another post. The caller must hold the write lock.
+func (svc *Service) checkQuota(userID int64, now time.Time) error {
+	if svc.quota.MaxPosts > 0 && svc.countPosts(userID) >= svc.quota.MaxPosts {
+		return &QuotaError{message: "maximum number of posts reached"}
+	}
+
+	if !svc.windowed() {
+		return nil
+	}
+
+	if recent := svc.recent(userID, now); len(recent) >= svc.quota.WindowLimit {
+		return &QuotaError{
+			message:    fmt.Sprintf("no more than %d posts may be created within %s", svc.quota.WindowLimit, svc.quota.Window),
+			retryAfter: recent[len(recent)-svc.quota.WindowLimit].Add(svc.quota.Window).Sub(now),
+		}
+	}
+
+	return nil
+}
+
+// recent drops creation times that have left the quota window and returns the remaining ones. The caller must
+// hold the write lock.
+func (svc *Service) recent(userID int64, now time.Time) []time.Time {
+	times := svc.created[userID]
+	i := 0
+	for i < len(times) && !times[i].After(now.Add(-svc.quota.Window)) {
+		i++
+	}
+
+	if i == len(times) {
+		delete(svc.created, userID)
+		return nil
+	}
+	svc.created[userID] = times[i:]
+
+	return times[i:]
+}
+
+func (svc *Service) windowed() bool {
+	return svc.quota.WindowLimit > 0 && svc.quota.Window > 0
+}
+
+func (svc *Service) countPosts(userID int64) int {
+	n := 0
+	for _, p := range svc.cache {
+		if p.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+func (svc *Service) isValidPost(ctx context.Context, post *Post) (err error) {
+	ctx, span := tracing.Start(ctx, "post.isValidPost", tracing.PhaseValidation)
+	defer span.End()
+
 	defer func(start time.Time) {
-		slog.Debug("validating post", slog.Duration("dur", time.Since(start)))
+		slog.DebugContext(ctx, "validating post", slog.Duration("dur", time.Since(start)))
+		svc.metrics.validationFailed(err)
 	}(time.Now())
 
 	if post == nil {
-		return &InvalidError{message: "post missing"}
+		return &InvalidError{message: "post missing", reason: "missing"}
 	}
 
 	if post.UserID < 1 {
-		return &InvalidError{message: "invalid userID"}
+		return &InvalidError{message: "invalid userID", reason: "user_id"}
 	}
 
-	if _, err := svc.userSvc.GetUser(post.UserID); err != nil {
+	if _, err := svc.userSvc.GetUser(ctx, post.UserID); err != nil {
 		var nf *user.NotFoundError
 		if errors.As(err, &nf) {
-			return &InvalidError{message: "userID was not found"}
+			return &InvalidError{message: "userID was not found", reason: "user_not_found"}
 		}
 
 		return fmt.Errorf("get user: %w", err)
@@ -130,13 +333,46 @@ func (svc *Service) isValidPost(post *Post) error {
 
 	titleLen := utf8.RuneCountInString(post.Title)
 	if titleLen < 2 || titleLen > 200 {
-		return &InvalidError{message: "title must be between 2 and 200 characters in length"}
+		return &InvalidError{message: "title must be between 2 and 200 characters in length", reason: "title_length"}
 	}
 
 	contentLen := utf8.RuneCountInString(post.Content)
 	if contentLen < 3 || contentLen > 5000 {
-		return &InvalidError{message: "content must be between 3 and 5000 characters in length"}
+		return &InvalidError{message: "content must be between 3 and 5000 characters in length", reason: "content_length"}
 	}
 
 	return nil
 }
+
+// snapshot is the state handed to a process replacing this one.
+type snapshot struct {
+	Posts   map[int64]Post
+	Created map[int64][]time.Time
+	LastID  int64
+}
+
+// Snapshot writes the stored posts and the creation times counted against quotas to w.
+func (svc *Service) Snapshot(w io.Writer) error {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(snapshot{Posts: svc.cache, Created: svc.created, LastID: svc.lastID.Load()})
+}
+
+// Restore replaces the stored posts with those written by Snapshot.
+func (svc *Service) Restore(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode posts: %w", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.cache = make(map[int64]Post, len(snap.Posts))
+	maps.Copy(svc.cache, snap.Posts)
+	svc.created = make(map[int64][]time.Time, len(snap.Created))
+	maps.Copy(svc.created, snap.Created)
+	svc.lastID.Store(snap.LastID)
+	return nil
+}
@@ -1,6 +1,8 @@
 package post
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"math"
 	"reflect"
@@ -8,16 +10,18 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jqdurham/rest-sample/internal/user"
 	userMocks "github.com/jqdurham/rest-sample/internal/user/mocks"
+	"github.com/stretchr/testify/mock"
 )
 
 var errMockedFailure = errors.New("mocked failure")
 
 func TestService_NewService(t *testing.T) {
 	t.Parallel()
-	got := NewService(userMocks.NewServicer(t))
+	got := NewService(userMocks.NewServicer(t), DefaultQuota)
 	if got == nil {
 		t.Errorf("NewService() returned nil")
 	}
@@ -30,7 +34,8 @@ func TestService_CreatePost(t *testing.T) {
 		userSvc func() user.Servicer
 	}
 	type args struct {
-		post *Post
+		actor Actor
+		post  *Post
 	}
 	tests := []struct {
 		name   string
@@ -45,12 +50,13 @@ func TestService_CreatePost(t *testing.T) {
 				lastID: 0,
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(9)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(9)).Return(nil, nil)
 					return m
 				},
 			},
 			args: args{
-				post: &Post{Title: "My Post", Content: "My Content", UserID: 9},
+				actor: Actor{UserID: 9},
+				post:  &Post{Title: "My Post", Content: "My Content", UserID: 9},
 			},
 			want: &Post{ID: 1, Title: "My Post", Content: "My Content", UserID: 9},
 		},
@@ -60,15 +66,46 @@ func TestService_CreatePost(t *testing.T) {
 				lastID: 1336,
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(1)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(1)).Return(nil, nil)
 					return m
 				},
 			},
 			args: args{
-				post: &Post{Title: "My Post", Content: "My Content", UserID: 1},
+				actor: Actor{UserID: 4, Admin: true},
+				post:  &Post{Title: "My Post", Content: "My Content", UserID: 1},
 			},
 			want: &Post{ID: 1337, Title: "My Post", Content: "My Content", UserID: 1},
 		},
+		{
+			name: "Defaults author to actor",
+			fields: fields{
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(3)).Return(nil, nil)
+					return m
+				},
+			},
+			args: args{
+				actor: Actor{UserID: 3},
+				post:  &Post{Title: "My Post", Content: "My Content"},
+			},
+			want: &Post{ID: 1, Title: "My Post", Content: "My Content", UserID: 3},
+		},
+		{
+			name: "Rejects post for another author",
+			fields: fields{
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(2)).Return(nil, nil)
+					return m
+				},
+			},
+			args: args{
+				actor: Actor{UserID: 3},
+				post:  &Post{Title: "My Post", Content: "My Content", UserID: 2},
+			},
+			errMsg: "posts may only be created for yourself",
+		},
 		{
 			name: "Rejects invalid post",
 			args: args{
@@ -89,7 +126,7 @@ func TestService_CreatePost(t *testing.T) {
 				svc.userSvc = tt.fields.userSvc()
 			}
 			svc.lastID.Store(tt.fields.lastID)
-			got, err := svc.CreatePost(tt.args.post)
+			got, err := svc.CreatePost(context.Background(), tt.args.actor, tt.args.post)
 			if err != nil && err.Error() != tt.errMsg {
 				t.Errorf("CreatePost() error = %v, errMsg %v", err, tt.errMsg)
 				return
@@ -107,7 +144,8 @@ func TestService_DeletePost(t *testing.T) {
 		cache map[int64]Post
 	}
 	type args struct {
-		id int64
+		actor Actor
+		id    int64
 	}
 	tests := []struct {
 		name   string
@@ -122,17 +160,42 @@ func TestService_DeletePost(t *testing.T) {
 				cache: map[int64]Post{1: {}, 2: {}, 3: {}},
 			},
 			args: args{
-				id: 2,
+				actor: Actor{Admin: true},
+				id:    2,
 			},
 			want: map[int64]Post{1: {}, 3: {}},
 		},
+		{
+			name: "Removes own post from cache",
+			fields: fields{
+				cache: map[int64]Post{1: {UserID: 7}, 2: {UserID: 8}},
+			},
+			args: args{
+				actor: Actor{UserID: 7},
+				id:    1,
+			},
+			want: map[int64]Post{2: {UserID: 8}},
+		},
+		{
+			name: "Returns Forbidden error when post belongs to another user",
+			fields: fields{
+				cache: map[int64]Post{1: {UserID: 7}, 2: {UserID: 8}},
+			},
+			args: args{
+				actor: Actor{UserID: 7},
+				id:    2,
+			},
+			want:   map[int64]Post{1: {UserID: 7}, 2: {UserID: 8}},
+			errMsg: "posts of other users may not be deleted",
+		},
 		{
 			name: "Returns NotFound error when post doesn't exist",
 			fields: fields{
 				cache: map[int64]Post{1: {}, 2: {}, 3: {}},
 			},
 			args: args{
-				id: 8,
+				actor: Actor{Admin: true},
+				id:    8,
 			},
 			want:   map[int64]Post{1: {}, 2: {}, 3: {}},
 			errMsg: "post with id 8 not found",
@@ -144,7 +207,7 @@ func TestService_DeletePost(t *testing.T) {
 			svc := &Service{
 				cache: tt.fields.cache,
 			}
-			err := svc.DeletePost(tt.args.id)
+			err := svc.DeletePost(context.Background(), tt.args.actor, tt.args.id)
 			if err != nil && err.Error() != tt.errMsg {
 				t.Errorf("DeletePost() error = %v, errMsg %v", err, tt.errMsg)
 			}
@@ -208,7 +271,7 @@ func TestService_GetPost(t *testing.T) {
 			svc := &Service{
 				cache: tt.fields.cache,
 			}
-			pst, err := svc.GetPost(tt.args.id)
+			pst, err := svc.GetPost(context.Background(), tt.args.id)
 			if err != nil && err.Error() != tt.errMsg {
 				t.Errorf("GetPost() error = %v, errMsg %v", err, tt.errMsg)
 			}
@@ -258,7 +321,7 @@ func TestService_ListPosts(t *testing.T) {
 			svc := &Service{
 				cache: tt.fields.cache,
 			}
-			if got := svc.ListPosts(); !reflect.DeepEqual(got, tt.want) {
+			if got := svc.ListPosts(context.Background()); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ListPosts() = %v, want %v", got, tt.want)
 			}
 		})
@@ -272,8 +335,9 @@ func TestService_UpdatePost(t *testing.T) {
 		userSvc func() user.Servicer
 	}
 	type args struct {
-		id   int64
-		post *Post
+		actor Actor
+		id    int64
+		post  *Post
 	}
 	tests := []struct {
 		name   string
@@ -288,34 +352,36 @@ func TestService_UpdatePost(t *testing.T) {
 				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}, 2: {}},
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(10)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(9)).Return(nil, nil)
 					return m
 				},
 			},
 			args: args{
-				id:   5,
-				post: &Post{ID: 10, Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
+				actor: Actor{UserID: 9},
+				id:    5,
+				post:  &Post{ID: 10, Title: "My NEW Post", Content: "My NEW Content", UserID: 9},
 			},
-			want: &Post{ID: 5, Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
+			want: &Post{ID: 5, Title: "My NEW Post", Content: "My NEW Content", UserID: 9},
 		},
 		{
-			name: "Returns NotFound when post doesn't exist",
+			name: "Keeps author when none is given",
 			fields: fields{
-				cache: map[int64]Post{},
+				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}},
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(10)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(9)).Return(nil, nil)
 					return m
 				},
 			},
 			args: args{
-				id:   5,
-				post: &Post{ID: 10, Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
+				actor: Actor{UserID: 1, Admin: true},
+				id:    5,
+				post:  &Post{Title: "My NEW Post", Content: "My NEW Content"},
 			},
-			errMsg: "post with id 5 not found",
+			want: &Post{ID: 5, Title: "My NEW Post", Content: "My NEW Content", UserID: 9},
 		},
 		{
-			name: "Returns validation failure when post fails validation",
+			name: "Returns NotFound when post doesn't exist",
 			fields: fields{
 				cache: map[int64]Post{},
 				userSvc: func() user.Servicer {
@@ -323,10 +389,58 @@ func TestService_UpdatePost(t *testing.T) {
 				},
 			},
 			args: args{
-				id:   5,
-				post: &Post{ID: 10, Title: "My Post", Content: "My Content", UserID: 0},
+				actor: Actor{Admin: true},
+				id:    5,
+				post:  &Post{ID: 10, Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
 			},
-			errMsg: "invalid userID",
+			errMsg: "post with id 5 not found",
+		},
+		{
+			name: "Returns Forbidden when post belongs to another user",
+			fields: fields{
+				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}},
+				userSvc: func() user.Servicer {
+					return userMocks.NewServicer(t)
+				},
+			},
+			args: args{
+				actor: Actor{UserID: 10},
+				id:    5,
+				post:  &Post{Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
+			},
+			errMsg: "posts of other users may not be updated",
+		},
+		{
+			name: "Rejects change of author",
+			fields: fields{
+				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}},
+				userSvc: func() user.Servicer {
+					return userMocks.NewServicer(t)
+				},
+			},
+			args: args{
+				actor: Actor{Admin: true},
+				id:    5,
+				post:  &Post{Title: "My NEW Post", Content: "My NEW Content", UserID: 10},
+			},
+			errMsg: "the author of a post may only be changed by transferring it",
+		},
+		{
+			name: "Returns validation failure when post fails validation",
+			fields: fields{
+				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}},
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(9)).Return(nil, nil)
+					return m
+				},
+			},
+			args: args{
+				actor: Actor{UserID: 9},
+				id:    5,
+				post:  &Post{Title: "😀", Content: "My Content"},
+			},
+			errMsg: "title must be between 2 and 200 characters in length",
 		},
 	}
 	for _, tt := range tests {
@@ -336,7 +450,7 @@ func TestService_UpdatePost(t *testing.T) {
 				cache:   tt.fields.cache,
 				userSvc: tt.fields.userSvc(),
 			}
-			got, err := svc.UpdatePost(tt.args.id, tt.args.post)
+			got, err := svc.UpdatePost(context.Background(), tt.args.actor, tt.args.id, tt.args.post)
 			if err != nil && err.Error() != tt.errMsg {
 				t.Errorf("UpdatePost() error = %v, errMsg %v", err, tt.errMsg)
 				return
@@ -348,6 +462,91 @@ func TestService_UpdatePost(t *testing.T) {
 	}
 }
 
+func TestService_TransferPost(t *testing.T) {
+	t.Parallel()
+	type fields struct {
+		userSvc func() user.Servicer
+	}
+	type args struct {
+		actor  Actor
+		id     int64
+		userID int64
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   *Post
+		errMsg string
+	}{
+		{
+			name: "Author transfers own post",
+			fields: fields{
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(10)).Return(nil, nil)
+					return m
+				},
+			},
+			args: args{actor: Actor{UserID: 9}, id: 5, userID: 10},
+			want: &Post{ID: 5, Title: "My Post", Content: "My Content", UserID: 10},
+		},
+		{
+			name: "Admin transfers post of another user",
+			fields: fields{
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(10)).Return(nil, nil)
+					return m
+				},
+			},
+			args: args{actor: Actor{UserID: 1, Admin: true}, id: 5, userID: 10},
+			want: &Post{ID: 5, Title: "My Post", Content: "My Content", UserID: 10},
+		},
+		{
+			name:   "Returns Forbidden when post belongs to another user",
+			args:   args{actor: Actor{UserID: 10}, id: 5, userID: 10},
+			errMsg: "posts of other users may not be transferred",
+		},
+		{
+			name:   "Returns NotFound when post doesn't exist",
+			args:   args{actor: Actor{Admin: true}, id: 6, userID: 10},
+			errMsg: "post with id 6 not found",
+		},
+		{
+			name: "Rejects unknown user",
+			fields: fields{
+				userSvc: func() user.Servicer {
+					m := userMocks.NewServicer(t)
+					m.On("GetUser", mock.Anything, int64(11)).Return(nil, &user.NotFoundError{})
+					return m
+				},
+			},
+			args:   args{actor: Actor{UserID: 9}, id: 5, userID: 11},
+			errMsg: "userID was not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			svc := &Service{
+				cache: map[int64]Post{5: {ID: 5, Title: "My Post", Content: "My Content", UserID: 9}},
+			}
+			if tt.fields.userSvc != nil {
+				svc.userSvc = tt.fields.userSvc()
+			}
+			got, err := svc.TransferPost(context.Background(), tt.args.actor, tt.args.id, tt.args.userID)
+			if err != nil && err.Error() != tt.errMsg {
+				t.Errorf("TransferPost() error = %v, errMsg %v", err, tt.errMsg)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TransferPost() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestService_isValidPost(t *testing.T) {
 	t.Parallel()
 	type fields struct {
@@ -367,7 +566,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(1)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(1)).Return(nil, nil)
 					return m
 				},
 			},
@@ -385,7 +584,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(1)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(1)).Return(nil, nil)
 					return m
 				},
 			},
@@ -403,7 +602,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(math.MaxInt64)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(math.MaxInt64)).Return(nil, nil)
 					return m
 				},
 			},
@@ -421,7 +620,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(math.MaxInt64)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(math.MaxInt64)).Return(nil, nil)
 					return m
 				},
 			},
@@ -455,7 +654,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(6)).Return(nil, &user.NotFoundError{})
+					m.On("GetUser", mock.Anything, int64(6)).Return(nil, &user.NotFoundError{})
 					return m
 				},
 			},
@@ -469,7 +668,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(5)).Return(nil, errMockedFailure)
+					m.On("GetUser", mock.Anything, int64(5)).Return(nil, errMockedFailure)
 					return m
 				},
 			},
@@ -483,7 +682,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(4)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(4)).Return(nil, nil)
 					return m
 				},
 			},
@@ -497,7 +696,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(4)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(4)).Return(nil, nil)
 					return m
 				},
 			},
@@ -511,7 +710,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(4)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(4)).Return(nil, nil)
 					return m
 				},
 			},
@@ -525,7 +724,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(4)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(4)).Return(nil, nil)
 					return m
 				},
 			},
@@ -539,7 +738,7 @@ func TestService_isValidPost(t *testing.T) {
 			fields: fields{
 				userSvc: func() user.Servicer {
 					m := userMocks.NewServicer(t)
-					m.On("GetUser", int64(4)).Return(nil, nil)
+					m.On("GetUser", mock.Anything, int64(4)).Return(nil, nil)
 					return m
 				},
 			},
@@ -556,7 +755,7 @@ func TestService_isValidPost(t *testing.T) {
 			if tt.fields.userSvc != nil {
 				svc.userSvc = tt.fields.userSvc()
 			}
-			err := svc.isValidPost(tt.args.post)
+			err := svc.isValidPost(context.Background(), tt.args.post)
 
 			if err != nil && err.Error() != tt.errMsg {
 				t.Errorf("isValidPost() error = %v, errMsg %v", err, tt.errMsg)
@@ -565,3 +764,95 @@ func TestService_isValidPost(t *testing.T) {
 		})
 	}
 }
+
+func TestService_CreatePost_quota(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		quota  Quota
+		want   Usage
+		errMsg string
+	}{
+		{
+			name:   "Rejects posts beyond window limit",
+			quota:  Quota{WindowLimit: 2, Window: time.Hour, MaxPosts: 10},
+			want:   Usage{Quota: Quota{WindowLimit: 2, Window: time.Hour, MaxPosts: 10}, Recent: 2, Posts: 2},
+			errMsg: "no more than 2 posts may be created within 1h0m0s",
+		},
+		{
+			name:   "Rejects posts beyond maximum",
+			quota:  Quota{WindowLimit: 10, Window: time.Hour, MaxPosts: 2},
+			want:   Usage{Quota: Quota{WindowLimit: 10, Window: time.Hour, MaxPosts: 2}, Recent: 2, Posts: 2},
+			errMsg: "maximum number of posts reached",
+		},
+		{
+			name:  "Allows unlimited posts without quota",
+			want:  Usage{Posts: 3},
+			quota: Quota{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := userMocks.NewServicer(t)
+			m.On("GetUser", mock.Anything, int64(1)).Return(nil, nil)
+			svc := NewService(m, tt.quota)
+
+			var err error
+			for range 3 {
+				_, err = svc.CreatePost(context.Background(), Actor{UserID: 1}, &Post{Title: "My Post", Content: "My Content"})
+			}
+
+			var qe *QuotaError
+			if tt.errMsg != "" && (!errors.As(err, &qe) || err.Error() != tt.errMsg) {
+				t.Fatalf("CreatePost() error = %v, errMsg %v", err, tt.errMsg)
+			}
+			if tt.errMsg == "" && err != nil {
+				t.Fatalf("CreatePost() error = %v", err)
+			}
+
+			got := svc.GetUsage(context.Background(), 1)
+			if (got.ResetAt != nil) != (tt.want.Recent > 0) {
+				t.Errorf("GetUsage() ResetAt = %v, want set %v", got.ResetAt, tt.want.Recent > 0)
+			}
+			got.ResetAt = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_SnapshotRestore(t *testing.T) {
+	t.Parallel()
+	m := userMocks.NewServicer(t)
+	m.On("GetUser", mock.Anything, int64(1)).Return(nil, nil)
+	quota := Quota{WindowLimit: 2, Window: time.Hour, MaxPosts: 10}
+	svc := NewService(m, quota)
+	for range 2 {
+		if _, err := svc.CreatePost(context.Background(), Actor{UserID: 1}, &Post{Title: "My Post", Content: "My Content"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := svc.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	restored := NewService(m, quota)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if got, want := restored.ListPosts(context.Background()), svc.ListPosts(context.Background()); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListPosts() after Restore() = %+v, want %+v", got, want)
+	}
+	if got := restored.GetUsage(context.Background(), 1); got.Recent != 2 {
+		t.Errorf("GetUsage() after Restore() Recent = %d, want 2", got.Recent)
+	}
+	m.On("GetUser", mock.Anything, int64(2)).Return(nil, nil)
+	p, err := restored.CreatePost(context.Background(), Actor{UserID: 2}, &Post{Title: "Next Post", Content: "My Content"})
+	if err != nil || p.ID != 3 {
+		t.Errorf("CreatePost() after Restore() = %+v, %v, want ID 3", p, err)
+	}
+}
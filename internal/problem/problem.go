@@ -0,0 +1,53 @@
+// Package problem writes RFC 9457 problem details responses.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jqdurham/rest-sample/internal/requestid"
+)
+
+// ContentType is the media type of problem details documents.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details document.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestID correlates the problem with the server logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// New creates a problem for status, titled with the standard status text.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write sends p as the response, using the request path as the problem instance when none is set. The
+// request ID is taken from the response headers, so that it is found even when r is not available.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get(requestid.Header)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a problem with the given status and detail.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
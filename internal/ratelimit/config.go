@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+//go:embed default.json
+var defaultConfig []byte
+
+// Limit describes a token bucket that holds up to Burst requests and refills at Rate requests per second.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Config holds the limit applied to every operation and overrides for individual operations, named as in
+// oapi.ServerInterface. Overridden operations are counted in buckets of their own. AuthFailures limits the
+// failed authentications per IP address; the default limit applies when it is unset.
+type Config struct {
+	Default      Limit            `json:"default"`
+	Operations   map[string]Limit `json:"operations"`
+	AuthFailures Limit            `json:"auth_failures"`
+}
+
+// Default returns the limits compiled into the binary.
+func Default() (*Config, error) {
+	return parse(defaultConfig)
+}
+
+// Load reads limits from a JSON file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rate limits: %w", err)
+	}
+	return parse(data)
+}
+
+func parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse rate limits: %w", err)
+	}
+	return &c, nil
+}
+
+// Validate confirms every limit is usable and every overridden operation exists.
+func (c *Config) Validate(operationIDs []string) error {
+	if err := c.Default.validate(); err != nil {
+		return &InvalidError{message: "default rate limit " + err.Error()}
+	}
+	if c.AuthFailures != (Limit{}) {
+		if err := c.AuthFailures.validate(); err != nil {
+			return &InvalidError{message: "rate limit of failed authentications " + err.Error()}
+		}
+	}
+	for id, lim := range c.Operations {
+		if !slices.Contains(operationIDs, id) {
+			return &InvalidError{message: fmt.Sprintf("rate limit for unknown operation %s", id)}
+		}
+		if err := lim.validate(); err != nil {
+			return &InvalidError{message: fmt.Sprintf("rate limit of %s %s", id, err)}
+		}
+	}
+	return nil
+}
+
+func (l Limit) validate() error {
+	if l.Rate <= 0 || l.Burst < 1 {
+		return &InvalidError{message: "must have a positive rate and a burst of at least 1"}
+	}
+	return nil
+}
+
+func (c *Config) authFailures() Limit {
+	if c.AuthFailures == (Limit{}) {
+		return c.Default
+	}
+	return c.AuthFailures
+}
@@ -0,0 +1,9 @@
+package ratelimit
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
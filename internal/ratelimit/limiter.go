@@ -0,0 +1,167 @@
+// Package ratelimit throttles clients with token buckets, configurable per OpenAPI operation.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Result describes the state of a client's bucket after a request was counted against it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, set for denied requests.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// Limiter tracks one token bucket per client and operation limit. Its limits may be replaced at runtime.
+type Limiter struct {
+	config  atomic.Pointer[Config]
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewLimiter(c *Config) *Limiter {
+	l := &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	l.config.Store(c)
+	return l
+}
+
+// Swap atomically replaces the active limits. Existing buckets adopt them on their next request.
+func (l *Limiter) Swap(c *Config) {
+	l.config.Store(c)
+}
+
+// Allow counts a request by client for the operation and reports whether it may proceed.
+func (l *Limiter) Allow(client, operationID string) Result {
+	cfg := l.config.Load()
+
+	key := client
+	lim, ok := cfg.Operations[operationID]
+	if ok {
+		key = operationID + " " + client
+	} else {
+		lim = cfg.Default
+	}
+
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(key, lim, now)
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return b.result(allowed)
+}
+
+// AllowAuthentication reports whether client may attempt to authenticate, without counting the attempt.
+func (l *Limiter) AllowAuthentication(client string) Result {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(authFailuresKey+client, l.config.Load().authFailures(), now)
+	return b.result(b.tokens >= 1)
+}
+
+// FailAuthentication counts a failed authentication by client. Failures are counted even when the bucket is
+// empty, so that guesses sent at the same time extend the wait instead of slipping through.
+func (l *Limiter) FailAuthentication(client string) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.bucket(authFailuresKey+client, l.config.Load().authFailures(), now).tokens--
+}
+
+// authFailuresKey prefixes the buckets counting failed authentications.
+const authFailuresKey = "auth failures "
+
+// bucket returns the refilled bucket of key, creating a full one. l.mu must be held.
+func (l *Limiter) bucket(key string, lim Limit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(lim.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(lim, now)
+	return b
+}
+
+// Run drops the buckets of idle clients every interval until ctx is done. A bucket that has refilled
+// completely is indistinguishable from a new one, so removing it loses nothing.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if removed := l.prune(); removed > 0 {
+				slog.Debug("idle rate limit buckets removed", slog.Int("count", removed))
+			}
+		}
+	}
+}
+
+func (l *Limiter) prune() int {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for k, b := range l.buckets {
+		b.refill(b.limit, now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, k)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+func (b *bucket) refill(lim Limit, now time.Time) {
+	b.limit = lim
+	b.tokens = math.Min(float64(lim.Burst), b.tokens+now.Sub(b.last).Seconds()*lim.Rate)
+	b.last = now
+}
+
+func (b *bucket) result(allowed bool) Result {
+	res := Result{Allowed: allowed, Limit: b.limit.Burst}
+	if !allowed {
+		res.RetryAfter = seconds((1 - b.tokens) / b.limit.Rate)
+	}
+	res.Remaining = int(math.Max(b.tokens, 0))
+	res.Reset = seconds((float64(b.limit.Burst) - b.tokens) / b.limit.Rate)
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Parallel()
+	start := time.Unix(1700000000, 0)
+
+	type request struct {
+		after     time.Duration
+		client    string
+		operation string
+		want      Result
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "Allows burst then denies",
+			requests: []request{
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+				{client: "a", want: Result{Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}},
+			},
+		},
+		{
+			name: "Refills over time",
+			requests: []request{
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+				{after: time.Second, client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+			},
+		},
+		{
+			name: "Separates clients",
+			requests: []request{
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+				{client: "a", want: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+				{client: "b", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+			},
+		},
+		{
+			name: "Counts overridden operations separately",
+			requests: []request{
+				{client: "a", operation: "CreateSession", want: Result{Allowed: true, Limit: 1, Remaining: 0, Reset: 10 * time.Second}},
+				{client: "a", operation: "CreateSession", want: Result{Limit: 1, Remaining: 0, Reset: 10 * time.Second, RetryAfter: 10 * time.Second}},
+				{client: "a", operation: "ListPosts", want: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			now := start
+			l := NewLimiter(&Config{
+				Default:    Limit{Rate: 1, Burst: 2},
+				Operations: map[string]Limit{"CreateSession": {Rate: 0.1, Burst: 1}},
+			})
+			l.now = func() time.Time { return now }
+
+			for i, req := range tt.requests {
+				now = now.Add(req.after)
+				if got := l.Allow(req.client, req.operation); got != req.want {
+					t.Errorf("Allow() request %d = %+v, want %+v", i, got, req.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_prune(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(&Config{Default: Limit{Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return now }
+
+	l.Allow("a", "")
+	l.Allow("b", "")
+	l.Allow("b", "")
+
+	now = now.Add(time.Second)
+	if got := l.prune(); got != 1 {
+		t.Errorf("prune() = %d, want 1", got)
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Errorf("prune() removed bucket that is still draining")
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+
+	"github.com/jqdurham/rest-sample/internal/auth"
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// Middleware throttles requests per client and sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers on every response. It must run after the caller was authenticated and the
+// operation resolved.
+func Middleware(l *Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := l.Allow(Client(r), operation.FromContext(r.Context()))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				problem.Error(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthFailures refuses requests from IP addresses that failed to authenticate too often, before their
+// credentials are checked, and counts every 401 response against the address. It must run before the caller is
+// authenticated, so that guessing credentials is throttled even though such requests never reach Middleware.
+func AuthFailures(l *Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientIP(r)
+			if res := l.AllowAuthentication(client); !res.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+				problem.Error(w, r, http.StatusTooManyRequests, "too many failed authentications")
+				return
+			}
+
+			if m := httpsnoop.CaptureMetrics(next, w, r); m.Code == http.StatusUnauthorized {
+				l.FailAuthentication(client)
+			}
+		})
+	}
+}
+
+// Client identifies the caller of a request: its local user or token subject when authenticated, its IP
+// address otherwise.
+func Client(r *http.Request) string {
+	if id, ok := auth.IdentityFromContext(r.Context()); ok {
+		if id.UserID > 0 {
+			return fmt.Sprintf("user:%d", id.UserID)
+		}
+		return "sub:" + id.Issuer + "|" + id.Subject
+	}
+	return clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
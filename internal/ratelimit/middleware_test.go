@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthFailures(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(&Config{
+		Default:      Limit{Rate: 1, Burst: 5},
+		AuthFailures: Limit{Rate: 0.1, Burst: 2},
+	})
+	l.now = func() time.Time { return now }
+	h := AuthFailures(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	requests := []struct {
+		addr      string
+		token     string
+		after     time.Duration
+		wantCode  int
+		wantRetry string
+	}{
+		{addr: "192.0.2.1:1000", token: "good", wantCode: http.StatusNoContent},
+		{addr: "192.0.2.1:1000", token: "bad", wantCode: http.StatusUnauthorized},
+		{addr: "192.0.2.1:1001", token: "bad", wantCode: http.StatusUnauthorized},
+		{addr: "192.0.2.1:1002", token: "good", wantCode: http.StatusTooManyRequests, wantRetry: "10"},
+		{addr: "192.0.2.2:1000", token: "bad", wantCode: http.StatusUnauthorized},
+		{addr: "192.0.2.1:1000", token: "good", after: 10 * time.Second, wantCode: http.StatusNoContent},
+	}
+	for i, req := range requests {
+		now = now.Add(req.after)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		r.RemoteAddr = req.addr
+		r.Header.Set("Authorization", "Bearer "+req.token)
+
+		h.ServeHTTP(w, r)
+		if w.Code != req.wantCode {
+			t.Errorf("request %d status = %d, want %d", i, w.Code, req.wantCode)
+		}
+		if got := w.Header().Get("Retry-After"); got != req.wantRetry {
+			t.Errorf("request %d Retry-After = %q, want %q", i, got, req.wantRetry)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package recovery turns handler panics into logged 500 responses instead of dropped connections.
+package recovery
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jqdurham/rest-sample/internal/problem"
+)
+
+// Middleware recovers panics raised while serving a request, logs them with their stack and counts them in
+// rest_http_panics_total, registered with reg. A 500 problem is sent when the response has not been started;
+// otherwise the connection is aborted, as a partial response cannot be corrected. Panics with
+// http.ErrAbortHandler are passed on untouched.
+func Middleware(reg prometheus.Registerer) func(http.Handler) http.Handler {
+	panics := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "rest",
+		Subsystem: "http",
+		Name:      "panics_total",
+		Help:      "Panics recovered while serving requests.",
+	})
+	reg.MustRegister(panics)
+
+	return func(next http.Handler) http.Handler {
+		return recoverer(next, panics)
+	}
+}
+
+func recoverer(next http.Handler, panics prometheus.Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := false
+		ww := httpsnoop.Wrap(w, httpsnoop.Hooks{
+			WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return func(code int) {
+					started = true
+					next(code)
+				}
+			},
+			Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return func(b []byte) (int, error) {
+					started = true
+					return next(b)
+				}
+			},
+			ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+				return func(src io.Reader) (int64, error) {
+					started = true
+					return next(src)
+				}
+			},
+		})
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+
+			panics.Inc()
+			slog.ErrorContext(r.Context(), "panic serving request",
+				slog.Any("panic", rec),
+				slog.String("method", r.Method),
+				slog.String("url", r.URL.String()),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			if started {
+				panic(http.ErrAbortHandler)
+			}
+			problem.Error(w, r, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,80 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantCode   int
+		wantPanic  error
+		wantPanics float64
+	}{
+		{
+			name:     "Serves request that does not panic",
+			handler:  func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:       "Responds 500 to panic before response started",
+			handler:    func(http.ResponseWriter, *http.Request) { panic("boom") },
+			wantCode:   http.StatusInternalServerError,
+			wantPanics: 1,
+		},
+		{
+			name: "Aborts response already started",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				panic("boom")
+			},
+			wantCode:   http.StatusOK,
+			wantPanic:  http.ErrAbortHandler,
+			wantPanics: 1,
+		},
+		{
+			name:      "Passes ErrAbortHandler on",
+			handler:   func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) },
+			wantCode:  http.StatusOK,
+			wantPanic: http.ErrAbortHandler,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/posts", nil)
+			reg := prometheus.NewPedanticRegistry()
+
+			defer func() {
+				rec := recover()
+				err, _ := rec.(error)
+				if !errors.Is(err, tt.wantPanic) || (rec != nil) != (tt.wantPanic != nil) {
+					t.Errorf("Middleware() panic = %v, want %v", rec, tt.wantPanic)
+				}
+				if w.Code != tt.wantCode {
+					t.Errorf("Middleware() status = %d, want %d", w.Code, tt.wantCode)
+				}
+				want := fmt.Sprintf(`# HELP rest_http_panics_total Panics recovered while serving requests.
+# TYPE rest_http_panics_total counter
+rest_http_panics_total %v
+`, tt.wantPanics)
+				if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			Middleware(reg)(tt.handler).ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package redact masks personal data, such as email addresses, credentials and client IP addresses, before it
+// is written to logs or error responses.
+package redact
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// IP modes.
+const (
+	IPKeep     = "keep"
+	IPTruncate = "truncate"
+	IPHash     = "hash"
+)
+
+// Config lists what is redacted. Names are matched case-insensitively.
+type Config struct {
+	// QueryParams are masked in URLs.
+	QueryParams []string `yaml:"query_params"`
+	// Headers are masked in logged HTTP headers.
+	Headers []string `yaml:"headers"`
+	// Fields are masked wherever they appear as log attribute keys or JSON object keys.
+	Fields []string `yaml:"fields"`
+	// IPMode is keep, truncate (to /24 or /48) or hash.
+	IPMode string `yaml:"ip_mode"`
+	// Emails masks the local part of email addresses found in free text.
+	Emails bool `yaml:"emails"`
+}
+
+// DefaultConfig masks credentials, email addresses and the query parameters naming them.
+var DefaultConfig = Config{
+	QueryParams: []string{"email", "password", "token", "access_token"},
+	Headers:     []string{"Authorization", "Cookie", "Set-Cookie", "X-CSRF-Token"},
+	Fields:      []string{"email", "password", "current_password", "token", "secret", "csrf_token", "password_hash"},
+	IPMode:      IPKeep,
+	Emails:      true,
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+var emailRE = regexp.MustCompile(`[\w.+'-]+@([\w-]+(\.[\w-]+)+)`)
+
+// Redactor applies a Config.
+type Redactor struct {
+	params  map[string]bool
+	headers map[string]bool
+	fields  map[string]bool
+	ipMode  string
+	emails  bool
+	// salt keys IP hashes, so that they are stable within the process but cannot be reversed by hashing the IPv4
+	// address space.
+	salt []byte
+}
+
+func New(cfg Config) (*Redactor, error) {
+	r := &Redactor{
+		params:  set(cfg.QueryParams),
+		headers: set(cfg.Headers),
+		fields:  set(cfg.Fields),
+		ipMode:  cfg.IPMode,
+		emails:  cfg.Emails,
+	}
+
+	switch cfg.IPMode {
+	case "", IPKeep, IPTruncate:
+	case IPHash:
+		r.salt = make([]byte, 16)
+		if _, err := rand.Read(r.salt); err != nil {
+			return nil, fmt.Errorf("generate ip salt: %w", err)
+		}
+	default:
+		return nil, &InvalidError{message: fmt.Sprintf("unknown ip redaction mode %q", cfg.IPMode)}
+	}
+
+	return r, nil
+}
+
+func set(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[strings.ToLower(n)] = true
+	}
+	return m
+}
+
+// Field reports whether values named name are masked.
+func (r *Redactor) Field(name string) bool {
+	return r.fields[strings.ToLower(name)]
+}
+
+// Text masks the email addresses found in s, keeping their domain.
+func (r *Redactor) Text(s string) string {
+	if !r.emails || !strings.Contains(s, "@") {
+		return s
+	}
+	return emailRE.ReplaceAllString(s, Mask+"@$1")
+}
+
+// URL masks the configured query parameters of a URL or request URI, then any email address left in it.
+func (r *Redactor) URL(s string) string {
+	i := strings.IndexByte(s, '?')
+	if i < 0 || len(r.params) == 0 {
+		return r.Text(s)
+	}
+
+	query, err := url.ParseQuery(s[i+1:])
+	if err != nil {
+		// An unparsable query is masked as a whole rather than risking a leak.
+		return r.Text(s[:i]) + "?" + Mask
+	}
+	for name, values := range query {
+		masked := r.params[strings.ToLower(name)]
+		for j := range values {
+			if masked {
+				values[j] = Mask
+				continue
+			}
+			values[j] = r.Text(values[j])
+		}
+	}
+	return r.Text(s[:i]) + "?" + query.Encode()
+}
+
+// Header returns a copy of h with the configured headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		if r.headers[strings.ToLower(name)] {
+			for i := range values {
+				values[i] = Mask
+			}
+		}
+	}
+	return out
+}
+
+// IP truncates or hashes an IP address, with or without port, according to the IP mode. The port is dropped.
+func (r *Redactor) IP(addr string) string {
+	if r.ipMode == "" || r.ipMode == IPKeep {
+		return addr
+	}
+
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return Mask
+	}
+
+	if r.ipMode == IPHash {
+		sum := sha256.Sum256(append(append([]byte{}, r.salt...), ip...))
+		return "ip-" + hex.EncodeToString(sum[:6])
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// Value masks the configured fields of a decoded JSON value, recursively, and the email addresses in its
+// strings.
+func (r *Redactor) Value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if r.Field(k) {
+				v[k] = Mask
+				continue
+			}
+			v[k] = r.Value(val)
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = r.Value(val)
+		}
+		return v
+	case string:
+		return r.Text(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,87 @@
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactor_URL(t *testing.T) {
+	t.Parallel()
+	r, err := New(DefaultConfig)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "Without query", url: "/users/1", want: "/users/1"},
+		{name: "Masks configured parameters", url: "/users?email=jane%40example.com&page=2", want: "/users?email=%5BREDACTED%5D&page=2"},
+		{name: "Matches parameters case-insensitively", url: "/users?Token=abc", want: "/users?Token=%5BREDACTED%5D"},
+		{name: "Masks emails in other parameters", url: "/users?q=jane@example.com", want: "/users?q=%5BREDACTED%5D%40example.com"},
+		{name: "Masks unparsable queries", url: "/users?email=%zz", want: "/users?" + Mask},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := r.URL(tt.url); got != tt.want {
+				t.Errorf("URL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_IP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		mode string
+		addr string
+		want string
+	}{
+		{name: "Keeps", mode: IPKeep, addr: "192.0.2.33:5123", want: "192.0.2.33:5123"},
+		{name: "Truncates IPv4", mode: IPTruncate, addr: "192.0.2.33:5123", want: "192.0.2.0"},
+		{name: "Truncates IPv6", mode: IPTruncate, addr: "[2001:db8:1:2::7]:443", want: "2001:db8:1::"},
+		{name: "Masks non IPs", mode: IPTruncate, addr: "localhost", want: Mask},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := New(Config{IPMode: tt.mode})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := r.IP(tt.addr); got != tt.want {
+				t.Errorf("IP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	r, err := New(Config{IPMode: IPHash})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a, b := r.IP("192.0.2.33:1"), r.IP("192.0.2.33:2")
+	if a != b || !strings.HasPrefix(a, "ip-") || strings.Contains(a, "192.0.2") {
+		t.Errorf("IP() hashes = %q, %q, want equal opaque hashes", a, b)
+	}
+}
+
+func TestRedactor_Header(t *testing.T) {
+	t.Parallel()
+	r, err := New(DefaultConfig)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := http.Header{"Authorization": {"Bearer secret"}, "Accept": {"application/json"}}
+
+	got := r.Header(h)
+	if got.Get("Authorization") != Mask || got.Get("Accept") != "application/json" {
+		t.Errorf("Header() = %v", got)
+	}
+	if h.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Header() modified its argument")
+	}
+}
@@ -0,0 +1,7 @@
+package session
+
+type NotFoundError struct{}
+
+func (e NotFoundError) Error() string {
+	return "session not found or expired"
+}
@@ -0,0 +1,9 @@
+package session
+
+//go:generate mockery --name=Servicer
+type Servicer interface {
+	CreateSession(userID int64) (*Session, error)
+	GetSession(token string) (*Session, error)
+	DeleteSession(token string) error
+	DeleteUserSessions(userID int64)
+}
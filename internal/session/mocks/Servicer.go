@@ -0,0 +1,110 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	session "github.com/jqdurham/rest-sample/internal/session"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Servicer is an autogenerated mock type for the Servicer type
+type Servicer struct {
+	mock.Mock
+}
+
+// CreateSession provides a mock function with given fields: userID
+func (_m *Servicer) CreateSession(userID int64) (*session.Session, error) {
+	ret := _m.Called(userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSession")
+	}
+
+	var r0 *session.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) (*session.Session, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(int64) *session.Session); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*session.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSession provides a mock function with given fields: token
+func (_m *Servicer) DeleteSession(token string) error {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserSessions provides a mock function with given fields: userID
+func (_m *Servicer) DeleteUserSessions(userID int64) {
+	_m.Called(userID)
+}
+
+// GetSession provides a mock function with given fields: token
+func (_m *Servicer) GetSession(token string) (*session.Session, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSession")
+	}
+
+	var r0 *session.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*session.Session, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) *session.Session); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*session.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewServicer creates a new instance of Servicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServicer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Servicer {
+	mock := &Servicer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
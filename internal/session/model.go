@@ -0,0 +1,17 @@
+package session
+
+import "time"
+
+type Session struct {
+	// Token is the secret handed to the client in the session cookie. Only its hash is kept by the service.
+	Token     string `json:"-"`
+	UserID    int64
+	CSRFToken string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Stats summarizes the stored sessions.
+type Stats struct {
+	Sessions int `json:"sessions"`
+}
@@ -0,0 +1,211 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is the absolute lifetime of a session.
+	DefaultTTL = 12 * time.Hour
+	// DefaultIdleTimeout is how long a session survives without being used.
+	DefaultIdleTimeout = 30 * time.Minute
+)
+
+// compile time check to make sure Servicer interface is satisfied.
+var _ Servicer = &Service{}
+
+type entry struct {
+	session  Session
+	lastSeen time.Time
+}
+
+type Service struct {
+	mu          sync.Mutex
+	cache       map[string]*entry
+	ttl         time.Duration
+	idleTimeout time.Duration
+}
+
+func NewService(ttl, idleTimeout time.Duration) *Service {
+	return &Service{
+		cache:       make(map[string]*entry),
+		ttl:         ttl,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Stats summarizes the stored sessions.
+func (svc *Service) Stats() Stats {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	return Stats{Sessions: len(svc.cache)}
+}
+
+func (svc *Service) CreateSession(userID int64) (*Session, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	csrf, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sess := Session{
+		UserID:    userID,
+		CSRFToken: csrf,
+		CreatedAt: now,
+		ExpiresAt: now.Add(svc.ttl),
+	}
+
+	svc.mu.Lock()
+	svc.cache[hashToken(token)] = &entry{session: sess, lastSeen: now}
+	svc.mu.Unlock()
+
+	sess.Token = token
+
+	return &sess, nil
+}
+
+// GetSession returns the live session identified by token and records its use.
+func (svc *Service) GetSession(token string) (*Session, error) {
+	key := hashToken(token)
+	now := time.Now()
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	e, ok := svc.cache[key]
+	if !ok {
+		return nil, &NotFoundError{}
+	}
+
+	if svc.expired(e, now) {
+		delete(svc.cache, key)
+		return nil, &NotFoundError{}
+	}
+
+	e.lastSeen = now
+	out := e.session
+
+	return &out, nil
+}
+
+func (svc *Service) DeleteSession(token string) error {
+	key := hashToken(token)
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if _, ok := svc.cache[key]; !ok {
+		return &NotFoundError{}
+	}
+
+	delete(svc.cache, key)
+
+	return nil
+}
+
+// DeleteUserSessions revokes every session belonging to userID.
+func (svc *Service) DeleteUserSessions(userID int64) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for k, e := range svc.cache {
+		if e.session.UserID == userID {
+			delete(svc.cache, k)
+		}
+	}
+}
+
+// Run removes expired sessions every interval until ctx is done.
+func (svc *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			svc.mu.Lock()
+			removed := 0
+			for k, e := range svc.cache {
+				if svc.expired(e, now) {
+					delete(svc.cache, k)
+					removed++
+				}
+			}
+			svc.mu.Unlock()
+
+			if removed > 0 {
+				slog.Debug("expired sessions removed", slog.Int("count", removed))
+			}
+		}
+	}
+}
+
+func (svc *Service) expired(e *entry, now time.Time) bool {
+	return now.After(e.session.ExpiresAt) || now.Sub(e.lastSeen) > svc.idleTimeout
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// snapshotEntry is an entry with exported fields, so that it can be encoded.
+type snapshotEntry struct {
+	Session  Session
+	LastSeen time.Time
+}
+
+// Snapshot writes the stored sessions, keyed by token hash, to w.
+func (svc *Service) Snapshot(w io.Writer) error {
+	svc.mu.Lock()
+	snap := make(map[string]snapshotEntry, len(svc.cache))
+	for hash, e := range svc.cache {
+		snap[hash] = snapshotEntry{Session: e.session, LastSeen: e.lastSeen}
+	}
+	svc.mu.Unlock()
+
+	return gob.NewEncoder(w).Encode(snap)
+}
+
+// Restore replaces the stored sessions with those written by Snapshot.
+func (svc *Service) Restore(r io.Reader) error {
+	var snap map[string]snapshotEntry
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode sessions: %w", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.cache = make(map[string]*entry, len(snap))
+	for hash, e := range snap {
+		svc.cache[hash] = &entry{session: e.Session, lastSeen: e.LastSeen}
+	}
+	return nil
+}
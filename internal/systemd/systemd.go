@@ -0,0 +1,123 @@
+// Package systemd integrates with the systemd service manager: it accepts sockets passed by socket activation
+// and reports readiness, shutdown and liveness over the notification socket. Outside of systemd it does nothing.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Notification states.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// Listeners returns the sockets passed by socket activation, keyed by their FileDescriptorName=, which defaults to
+// the name of the socket unit. The activation variables are removed from the environment, so that they do not
+// leak into child processes.
+func Listeners() (map[string][]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	out := make(map[string][]net.Listener)
+	for i := range n {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("activated socket %d (%s): %w", fd, name, err)
+		}
+		out[name] = append(out[name], ln)
+	}
+	return out, nil
+}
+
+// Notify sends a state, such as Ready, to the service manager. It reports false when the process was not started
+// with a notification socket.
+func Notify(state string) (bool, error) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return false, nil
+	}
+	// Abstract sockets are announced with a leading @.
+	if addr[0] == '@' {
+		addr = "\x00" + addr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return true, fmt.Errorf("dial notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return true, fmt.Errorf("notify %s: %w", state, err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval within which the service manager expects keep-alive pings, false when
+// its watchdog is disabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog pings the service manager at half the watchdog interval while healthy reports true, until ctx is
+// done. Once pings stop, the service manager restarts the process.
+func RunWatchdog(ctx context.Context, interval time.Duration, healthy func() bool) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !healthy() {
+				slog.WarnContext(ctx, "unhealthy, withholding watchdog ping")
+				continue
+			}
+			if _, err := Notify(Watchdog); err != nil {
+				slog.WarnContext(ctx, "watchdog ping failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
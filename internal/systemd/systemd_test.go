@@ -0,0 +1,66 @@
+package systemd
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if ok, err := Notify(Ready); ok || err != nil {
+		t.Fatalf("Notify() without socket = %v, %v, want false, nil", ok, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if ok, err := Notify(Ready); !ok || err != nil {
+		t.Fatalf("Notify() = %v, %v, want true, nil", ok, err)
+	}
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != Ready {
+		t.Errorf("received %q, want %q", got, Ready)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(1<<30))
+	if _, ok := WatchdogInterval(); ok {
+		t.Error("WatchdogInterval() enabled for another process")
+	}
+	t.Setenv("WATCHDOG_PID", "")
+	interval, ok := WatchdogInterval()
+	if !ok || interval != 20*time.Millisecond {
+		t.Fatalf("WatchdogInterval() = %v, %v, want 20ms, true", interval, ok)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunWatchdog(ctx, interval, func() bool { return true })
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err = conn.Read(buf); err != nil || string(buf[:n]) != Watchdog {
+		t.Errorf("received %q, %v, want %q", buf[:n], err, Watchdog)
+	}
+}
+
+func TestListenersNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "2")
+	lns, err := Listeners()
+	if err != nil || lns != nil {
+		t.Fatalf("Listeners() = %v, %v, want none for another process", lns, err)
+	}
+}
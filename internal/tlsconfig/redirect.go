@@ -0,0 +1,22 @@
+package tlsconfig
+
+import (
+	"net"
+	"net/http"
+)
+
+// Redirect answers plain HTTP requests with a permanent redirect to the same URL on HTTPS port httpsPort. The
+// default port 443 is left implicit.
+func Redirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		// 308 keeps the method and body of the request, unlike 301.
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,225 @@
+// Package tlsconfig terminates TLS for the servers. The certificate, key and client CA bundle are reloaded when
+// their files change, so that renewed certificates are served without a restart.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client authentication modes.
+const (
+	// ClientAuthNone does not ask for client certificates.
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies client certificates that are presented against the client CA bundle.
+	ClientAuthOptional = "optional"
+	// ClientAuthRequire rejects connections without a client certificate signed by the client CA bundle.
+	ClientAuthRequire = "require"
+)
+
+// Config configures TLS. TLS is disabled without a certificate.
+type Config struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	// MinVersion is 1.0, 1.1, 1.2 or 1.3.
+	MinVersion string `yaml:"min_version"`
+	// CipherSuites name the TLS 1.0-1.2 cipher suites offered, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256. Empty
+	// selects Go's defaults. TLS 1.3 suites are not configurable.
+	CipherSuites []string `yaml:"cipher_suites"`
+	// ClientCA is a PEM bundle of the CAs client certificates are verified against.
+	ClientCA   string `yaml:"client_ca"`
+	ClientAuth string `yaml:"client_auth"`
+	// RedirectAddr, if set, is listened on for plain HTTP requests, which are redirected to HTTPS.
+	RedirectAddr string `yaml:"redirect_addr"`
+}
+
+// Enabled reports whether TLS is configured.
+func (c Config) Enabled() bool {
+	return c.Cert != ""
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Validate rejects incomplete or unknown settings. It does not read the files.
+func (c Config) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return &InvalidError{message: "tls cert and key must be given together"}
+	}
+	if _, ok := versions[c.MinVersion]; !ok {
+		return &InvalidError{message: fmt.Sprintf("unknown minimum TLS version %q, want 1.0, 1.1, 1.2 or 1.3", c.MinVersion)}
+	}
+	if _, err := cipherSuites(c.CipherSuites); err != nil {
+		return err
+	}
+
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthOptional, ClientAuthRequire:
+		if c.ClientCA == "" {
+			return &InvalidError{message: "tls client authentication requires a client CA bundle"}
+		}
+	default:
+		return &InvalidError{message: fmt.Sprintf("unknown tls client authentication %q, want none, optional or require", c.ClientAuth)}
+	}
+
+	if !c.Enabled() && (c.ClientCA != "" || c.RedirectAddr != "") {
+		return &InvalidError{message: "tls client CA and redirect address require a certificate"}
+	}
+	return nil
+}
+
+func cipherSuites(names []string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range names {
+		i := slices.IndexFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool { return s.Name == name })
+		if i < 0 {
+			return nil, &InvalidError{message: fmt.Sprintf("unknown or insecure cipher suite %q", name)}
+		}
+		ids = append(ids, tls.CipherSuites()[i].ID)
+	}
+	return ids, nil
+}
+
+// Certificates holds the TLS settings in effect and reloads their files.
+type Certificates struct {
+	cfg     Config
+	current atomic.Pointer[tls.Config]
+
+	mu       sync.Mutex
+	modTimes []time.Time
+}
+
+// NewCertificates loads the files named by cfg, which must be valid and enabled.
+func NewCertificates(cfg Config) (*Certificates, error) {
+	c := &Certificates{cfg: cfg}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload re-reads the certificate, key and client CA bundle and atomically replaces the settings of new
+// connections. Files that fail to load leave the previous settings in place.
+func (c *Certificates) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTimes, err := c.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.cfg.Cert, c.cfg.Key)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+	suites, err := cipherSuites(c.cfg.CipherSuites)
+	if err != nil {
+		return err
+	}
+
+	out := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   versions[c.cfg.MinVersion],
+		CipherSuites: suites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if c.cfg.ClientCA != "" {
+		pem, err := os.ReadFile(c.cfg.ClientCA)
+		if err != nil {
+			return fmt.Errorf("read client CA bundle: %w", err)
+		}
+		out.ClientCAs = x509.NewCertPool()
+		if !out.ClientCAs.AppendCertsFromPEM(pem) {
+			return &InvalidError{message: fmt.Sprintf("no certificates found in client CA bundle %s", c.cfg.ClientCA)}
+		}
+		out.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.cfg.ClientAuth == ClientAuthRequire {
+			out.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	c.current.Store(out)
+	c.modTimes = modTimes
+	return nil
+}
+
+func (c *Certificates) stat() ([]time.Time, error) {
+	var out []time.Time
+	for _, path := range []string{c.cfg.Cert, c.cfg.Key, c.cfg.ClientCA} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat tls file: %w", err)
+		}
+		out = append(out, info.ModTime())
+	}
+	return out, nil
+}
+
+// TLSConfig returns the server configuration. Each connection uses the settings in effect when it is accepted.
+func (c *Certificates) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: versions[c.cfg.MinVersion],
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.current.Load(), nil
+		},
+	}
+}
+
+// Watch polls the files and reloads them whenever their modification times change, until ctx is done.
+func (c *Certificates) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTimes, err := c.stat()
+			if err != nil {
+				slog.Warn("tls stat failed", "error", err)
+				continue
+			}
+
+			c.mu.Lock()
+			changed := !slices.EqualFunc(modTimes, c.modTimes, time.Time.Equal)
+			c.mu.Unlock()
+			if !changed {
+				continue
+			}
+
+			// Certificate and key are usually replaced one after the other, a mismatch is retried on the next tick.
+			if err := c.Reload(); err != nil {
+				slog.Error("tls reload failed, keeping previous certificates", "error", err)
+				continue
+			}
+			slog.Info("tls certificates reloaded", "cert", c.cfg.Cert)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCert(t *testing.T, serial int64, subject pkix.Name, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if keyFile == "" {
+		return
+	}
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *testCert) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestCertificates(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		Cert:       filepath.Join(dir, "server.pem"),
+		Key:        filepath.Join(dir, "server.key"),
+		MinVersion: "1.2",
+		ClientCA:   filepath.Join(dir, "ca.pem"),
+		ClientAuth: ClientAuthRequire,
+	}
+	ca := newCert(t, 1, pkix.Name{CommonName: "test ca"}, nil, x509.ExtKeyUsageAny)
+	ca.write(t, cfg.ClientCA, "")
+	newCert(t, 2, pkix.Name{CommonName: "localhost"}, ca, x509.ExtKeyUsageServerAuth).write(t, cfg.Cert, cfg.Key)
+	client := newCert(t, 3, pkix.Name{CommonName: "42", OrganizationalUnit: []string{"admin"}}, ca, x509.ExtKeyUsageClientAuth)
+
+	certs, err := NewCertificates(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	}))
+	srv.TLS = certs.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCerts ...tls.Certificate) (*x509.Certificate, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: clientCerts,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0], nil
+	}
+
+	served, err := get(client.tls())
+	if err != nil {
+		t.Fatalf("request with client certificate failed: %v", err)
+	}
+	if served.SerialNumber.Int64() != 2 {
+		t.Errorf("served serial = %v, want 2", served.SerialNumber)
+	}
+	if _, err := get(); err == nil {
+		t.Error("request without client certificate succeeded")
+	}
+
+	newCert(t, 4, pkix.Name{CommonName: "localhost"}, ca, x509.ExtKeyUsageServerAuth).write(t, cfg.Cert, cfg.Key)
+	if err := certs.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if served, err = get(client.tls()); err != nil || served.SerialNumber.Int64() != 4 {
+		t.Errorf("after reload served %v, %v, want serial 4", served, err)
+	}
+
+	if err := os.WriteFile(cfg.Key, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := certs.Reload(); err == nil {
+		t.Error("Reload() accepted a broken key")
+	}
+	if served, err = get(client.tls()); err != nil || served.SerialNumber.Int64() != 4 {
+		t.Errorf("after failed reload served %v, %v, want serial 4", served, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "disabled", cfg: Config{MinVersion: "1.2"}},
+		{name: "enabled", cfg: Config{Cert: "c", Key: "k", MinVersion: "1.3", ClientCA: "ca", ClientAuth: ClientAuthOptional}},
+		{name: "cipher suites", cfg: Config{Cert: "c", Key: "k", MinVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}},
+		{name: "key without cert", cfg: Config{Key: "k", MinVersion: "1.2"}, wantErr: true},
+		{name: "unknown version", cfg: Config{Cert: "c", Key: "k", MinVersion: "1.4"}, wantErr: true},
+		{name: "insecure suite", cfg: Config{Cert: "c", Key: "k", MinVersion: "1.2", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}, wantErr: true},
+		{name: "client auth without CA", cfg: Config{Cert: "c", Key: "k", MinVersion: "1.2", ClientAuth: ClientAuthRequire}, wantErr: true},
+		{name: "redirect without cert", cfg: Config{MinVersion: "1.2", RedirectAddr: ":80"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		port, host, want string
+	}{
+		{port: "8443", host: "example.com:8080", want: "https://example.com:8443/posts?limit=1"},
+		{port: "443", host: "example.com", want: "https://example.com/posts?limit=1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://"+tt.host+"/posts?limit=1", nil)
+		w := httptest.NewRecorder()
+		Redirect(tt.port).ServeHTTP(w, r)
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tt.want {
+			t.Errorf("Redirect(%q) = %d %q, want 308 %q", tt.port, w.Code, w.Header().Get("Location"), tt.want)
+		}
+	}
+}
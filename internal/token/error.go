@@ -0,0 +1,27 @@
+package token
+
+import "fmt"
+
+type NotFoundError struct {
+	id int64
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("token with id %d not found", e.id)
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+type AuthenticationError struct {
+	message string
+}
+
+func (e AuthenticationError) Error() string {
+	return e.message
+}
@@ -0,0 +1,12 @@
+package token
+
+import "time"
+
+//go:generate mockery --name=Servicer
+type Servicer interface {
+	ListTokens(userID int64) []Token
+	CreateToken(userID int64, name string, scopes []string, expiresAt *time.Time) (*Token, string, error)
+	DeleteToken(userID, id int64) error
+	DeleteUserTokens(userID int64)
+	Authenticate(secret string) (*Token, error)
+}
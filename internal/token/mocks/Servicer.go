@@ -0,0 +1,140 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	token "github.com/jqdurham/rest-sample/internal/token"
+)
+
+// Servicer is an autogenerated mock type for the Servicer type
+type Servicer struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: secret
+func (_m *Servicer) Authenticate(secret string) (*token.Token, error) {
+	ret := _m.Called(secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 *token.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*token.Token, error)); ok {
+		return rf(secret)
+	}
+	if rf, ok := ret.Get(0).(func(string) *token.Token); ok {
+		r0 = rf(secret)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*token.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(secret)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateToken provides a mock function with given fields: userID, name, scopes, expiresAt
+func (_m *Servicer) CreateToken(userID int64, name string, scopes []string, expiresAt *time.Time) (*token.Token, string, error) {
+	ret := _m.Called(userID, name, scopes, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateToken")
+	}
+
+	var r0 *token.Token
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(int64, string, []string, *time.Time) (*token.Token, string, error)); ok {
+		return rf(userID, name, scopes, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(int64, string, []string, *time.Time) *token.Token); ok {
+		r0 = rf(userID, name, scopes, expiresAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*token.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, string, []string, *time.Time) string); ok {
+		r1 = rf(userID, name, scopes, expiresAt)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(int64, string, []string, *time.Time) error); ok {
+		r2 = rf(userID, name, scopes, expiresAt)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteToken provides a mock function with given fields: userID, id
+func (_m *Servicer) DeleteToken(userID int64, id int64) error {
+	ret := _m.Called(userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
+		r0 = rf(userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserTokens provides a mock function with given fields: userID
+func (_m *Servicer) DeleteUserTokens(userID int64) {
+	_m.Called(userID)
+}
+
+// ListTokens provides a mock function with given fields: userID
+func (_m *Servicer) ListTokens(userID int64) []token.Token {
+	ret := _m.Called(userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTokens")
+	}
+
+	var r0 []token.Token
+	if rf, ok := ret.Get(0).(func(int64) []token.Token); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]token.Token)
+		}
+	}
+
+	return r0
+}
+
+// NewServicer creates a new instance of Servicer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServicer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Servicer {
+	mock := &Servicer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
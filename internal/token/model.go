@@ -0,0 +1,34 @@
+package token
+
+import "time"
+
+// Scopes a personal access token may be granted.
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+)
+
+// Prefix starts every personal access token so they can be told apart from JWTs.
+const Prefix = "rsp_"
+
+type Token struct {
+	ID     int64
+	UserID int64
+	Name   string
+	// DisplayPrefix is the leading part of the secret, safe to show to identify the token.
+	DisplayPrefix string
+	// Hash is the SHA-256 digest of the secret; the secret itself is never stored.
+	Hash       string `json:"-"`
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// Stats summarizes the stored personal access tokens.
+type Stats struct {
+	Tokens  int `json:"tokens"`
+	Expired int `json:"expired"`
+}
@@ -0,0 +1,220 @@
+package token
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// displayPrefixLen is the number of leading secret characters kept to identify a token.
+const displayPrefixLen = len(Prefix) + 6
+
+// compile time check to make sure Servicer interface is satisfied.
+var _ Servicer = &Service{}
+
+type Service struct {
+	mu     sync.RWMutex
+	cache  map[int64]Token
+	byHash map[string]int64
+	lastID atomic.Int64
+}
+
+func NewService() *Service {
+	return &Service{
+		cache:  make(map[int64]Token),
+		byHash: make(map[string]int64),
+	}
+}
+
+// Stats summarizes the stored tokens.
+func (svc *Service) Stats() Stats {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	now := time.Now()
+	out := Stats{Tokens: len(svc.cache)}
+	for _, t := range svc.cache {
+		if t.ExpiresAt != nil && now.After(*t.ExpiresAt) {
+			out.Expired++
+		}
+	}
+	return out
+}
+
+func (svc *Service) ListTokens(userID int64) []Token {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	out := make([]Token, 0)
+	for _, v := range svc.cache {
+		if v.UserID == userID {
+			out = append(out, v)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b Token) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return out
+}
+
+// CreateToken mints a token for userID. The returned secret is only available at creation time.
+func (svc *Service) CreateToken(userID int64, name string, scopes []string, expiresAt *time.Time) (*Token, string, error) {
+	if err := isValidToken(name, scopes, expiresAt); err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("generate token: %w", err)
+	}
+	secret := Prefix + base64.RawURLEncoding.EncodeToString(b)
+
+	tkn := Token{
+		ID:            svc.lastID.Add(1),
+		UserID:        userID,
+		Name:          name,
+		DisplayPrefix: secret[:displayPrefixLen],
+		Hash:          hashSecret(secret),
+		Scopes:        slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt:     time.Now(),
+		ExpiresAt:     expiresAt,
+	}
+
+	svc.mu.Lock()
+	svc.cache[tkn.ID] = tkn
+	svc.byHash[tkn.Hash] = tkn.ID
+	svc.mu.Unlock()
+
+	return &tkn, secret, nil
+}
+
+func (svc *Service) DeleteToken(userID, id int64) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	tkn, ok := svc.cache[id]
+	if !ok || tkn.UserID != userID {
+		return &NotFoundError{id: id}
+	}
+
+	delete(svc.byHash, tkn.Hash)
+	delete(svc.cache, id)
+
+	return nil
+}
+
+// DeleteUserTokens revokes every token belonging to userID.
+func (svc *Service) DeleteUserTokens(userID int64) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for id, tkn := range svc.cache {
+		if tkn.UserID == userID {
+			delete(svc.byHash, tkn.Hash)
+			delete(svc.cache, id)
+		}
+	}
+}
+
+// Authenticate resolves the token matching secret and records its use.
+func (svc *Service) Authenticate(secret string) (*Token, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return nil, &AuthenticationError{message: "not a personal access token"}
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	id, ok := svc.byHash[hashSecret(secret)]
+	if !ok {
+		return nil, &AuthenticationError{message: "unknown or revoked token"}
+	}
+
+	tkn := svc.cache[id]
+	now := time.Now()
+	if tkn.ExpiresAt != nil && now.After(*tkn.ExpiresAt) {
+		return nil, &AuthenticationError{message: "token has expired"}
+	}
+
+	tkn.LastUsedAt = &now
+	svc.cache[id] = tkn
+
+	return &tkn, nil
+}
+
+func isValidToken(name string, scopes []string, expiresAt *time.Time) error {
+	nameLen := utf8.RuneCountInString(name)
+	if nameLen < 1 || nameLen > 100 {
+		return &InvalidError{message: "name must be between 1 and 100 characters in length"}
+	}
+
+	if len(scopes) == 0 {
+		return &InvalidError{message: "at least one scope is required"}
+	}
+
+	for _, s := range scopes {
+		switch s {
+		case ScopeUsersRead, ScopeUsersWrite, ScopePostsRead, ScopePostsWrite:
+		default:
+			return &InvalidError{message: fmt.Sprintf("unknown scope %q", s)}
+		}
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return &InvalidError{message: "expiry must be in the future"}
+	}
+
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// snapshot is the state handed to a process replacing this one.
+type snapshot struct {
+	Tokens map[int64]Token
+	LastID int64
+}
+
+// Snapshot writes the stored tokens, secret hashes included, to w.
+func (svc *Service) Snapshot(w io.Writer) error {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(snapshot{Tokens: svc.cache, LastID: svc.lastID.Load()})
+}
+
+// Restore replaces the stored tokens with those written by Snapshot.
+func (svc *Service) Restore(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode tokens: %w", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.cache = make(map[int64]Token, len(snap.Tokens))
+	svc.byHash = make(map[string]int64, len(snap.Tokens))
+	for id, t := range snap.Tokens {
+		svc.cache[id] = t
+		svc.byHash[t.Hash] = id
+	}
+	svc.lastID.Store(snap.LastID)
+	return nil
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jqdurham/rest-sample/internal/operation"
+	"github.com/jqdurham/rest-sample/internal/requestid"
+)
+
+// Middleware starts a server span for each request, continuing the trace of an incoming traceparent header, and
+// names it after the resolved operation. With serverTiming the response carries a Server-Timing header
+// summarizing the phases timed while serving the request. It must run after the operation has been resolved.
+func Middleware(serverTiming bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			name := r.Method
+			op := operation.FromContext(ctx)
+			if op != "" {
+				name += " " + op
+			}
+			ctx, span := otel.Tracer(Name).Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					attribute.String("operation", op),
+					attribute.String("request_id", requestid.FromContext(ctx)),
+				),
+			)
+			defer span.End()
+
+			if serverTiming {
+				t := newTimings()
+				ctx = context.WithValue(ctx, timingsKey{}, t)
+				w = withServerTiming(w, t)
+			}
+
+			code := http.StatusOK
+			w = httpsnoop.Wrap(w, httpsnoop.Hooks{
+				WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+					return func(c int) {
+						code = c
+						next(c)
+					}
+				},
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+			if code >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(code))
+			}
+		})
+	}
+}
+
+// withServerTiming sets the Server-Timing header just before the response header is sent.
+func withServerTiming(w http.ResponseWriter, t *timings) http.ResponseWriter {
+	written := false
+	set := func() {
+		if written {
+			return
+		}
+		written = true
+		if v := t.header(); v != "" {
+			w.Header().Set("Server-Timing", v)
+		}
+	}
+
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				set()
+				next(code)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				set()
+				return next(b)
+			}
+		},
+	})
+}
+
+type stageKey struct{}
+
+type stage struct {
+	span   trace.Span
+	parent trace.Span
+}
+
+// Stage wraps the middleware mw in a span named name, timed as phase. The span only covers the work done by mw
+// itself: it ends when mw passes the request on, and later spans are parented on the enclosing span again.
+func Stage(name, phase string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		inner := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if s, ok := ctx.Value(stageKey{}).(*stage); ok {
+				s.span.End()
+				ctx = trace.ContextWithSpan(ctx, s.parent)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent := trace.SpanFromContext(r.Context())
+			ctx, span := Start(r.Context(), name, phase)
+			defer span.End()
+
+			inner.ServeHTTP(w, r.WithContext(context.WithValue(ctx, stageKey{}, &stage{span: span, parent: parent})))
+		})
+	}
+}
+
+// Handler wraps the operation handlers in a span named after the operation.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := Start(r.Context(), "handler "+operation.FromContext(r.Context()), "")
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of the Server-Timing breakdown.
+const (
+	PhaseValidation = "validation"
+	PhaseStorage    = "storage"
+	PhaseEncoding   = "encoding"
+)
+
+type timingsKey struct{}
+
+type frame struct {
+	phase string
+	since time.Time
+}
+
+// timings accumulates the exclusive time spent in each phase of a request: while a nested phase runs, the
+// enclosing one is paused.
+type timings struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	totals map[string]time.Duration
+	order  []string
+	stack  []frame
+}
+
+func newTimings() *timings {
+	return &timings{now: time.Now, totals: make(map[string]time.Duration)}
+}
+
+func timingsFromContext(ctx context.Context) *timings {
+	t, _ := ctx.Value(timingsKey{}).(*timings)
+	return t
+}
+
+func (t *timings) start(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if n := len(t.stack); n > 0 {
+		t.add(t.stack[n-1].phase, now.Sub(t.stack[n-1].since))
+	}
+	t.stack = append(t.stack, frame{phase: phase, since: now})
+}
+
+func (t *timings) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := len(t.stack)
+	if n == 0 {
+		return
+	}
+	now := t.now()
+	top := t.stack[n-1]
+	t.stack = t.stack[:n-1]
+	t.add(top.phase, now.Sub(top.since))
+	if n > 1 {
+		t.stack[n-2].since = now
+	}
+}
+
+func (t *timings) add(phase string, d time.Duration) {
+	if _, ok := t.totals[phase]; !ok {
+		t.order = append(t.order, phase)
+	}
+	t.totals[phase] += d
+}
+
+// header formats the phases recorded so far as a Server-Timing header value, in milliseconds.
+func (t *timings) header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := make([]string, 0, len(t.order))
+	for _, phase := range t.order {
+		parts = append(parts, fmt.Sprintf("%s;dur=%.3f", phase, float64(t.totals[phase])/float64(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
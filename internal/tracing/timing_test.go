@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimings_header(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	tm := newTimings()
+	tm.now = func() time.Time { return now }
+	advance := func(d time.Duration) { now = now.Add(d) }
+
+	// storage encloses validation, which must not be counted as storage time.
+	tm.start(PhaseStorage)
+	advance(2 * time.Millisecond)
+	tm.start(PhaseValidation)
+	advance(5 * time.Millisecond)
+	tm.stop()
+	advance(time.Millisecond)
+	tm.stop()
+	tm.start(PhaseEncoding)
+	advance(500 * time.Microsecond)
+	tm.stop()
+	// unbalanced stops are ignored.
+	tm.stop()
+
+	want := "storage;dur=3.000, validation;dur=5.000, encoding;dur=0.500"
+	if got := tm.header(); got != want {
+		t.Errorf("header() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+// Package tracing instruments requests with OpenTelemetry spans and, optionally, summarizes where their time went
+// in a Server-Timing header. Spans are exported over OTLP or written to stdout or a file for local use.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name identifies the instrumentation of the application.
+const Name = "github.com/jqdurham/rest-sample"
+
+// Exporters supported by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config selects where spans are exported.
+type Config struct {
+	// Exporter is one of none, otlp, stdout or file.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. localhost:4318. The OTEL_EXPORTER_OTLP_* environment
+	// variables apply when it is empty.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure sends OTLP without TLS.
+	Insecure bool `yaml:"insecure"`
+	// File receives the spans of the file exporter.
+	File string `yaml:"file"`
+	// SampleRatio is the fraction of new traces recorded; sampling decisions of callers are honoured.
+	SampleRatio float64 `yaml:"sample_ratio"`
+	// ServiceName names the service in exported spans.
+	ServiceName string `yaml:"service_name"`
+}
+
+type InvalidError struct {
+	message string
+}
+
+func (e InvalidError) Error() string {
+	return e.message
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator. The returned function flushes
+// and stops the exporter. Without an exporter spans are not recorded, but incoming trace context is still propagated.
+func Setup(ctx context.Context, cfg Config) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, &InvalidError{message: "trace file is required by the file exporter"}
+		}
+		var f *os.File
+		f, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		closer = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, &InvalidError{message: fmt.Sprintf("unknown trace exporter %q", cfg.Exporter)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span named name and times it as phase of the request's Server-Timing breakdown, unless phase is
+// empty. Time spent in nested phases is not counted twice. Ending the span ends the phase.
+func Start(ctx context.Context, name, phase string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(Name).Start(ctx, name)
+	if t := timingsFromContext(ctx); t != nil && phase != "" {
+		t.start(phase)
+		return ctx, &phaseSpan{Span: span, timings: t}
+	}
+	return ctx, span
+}
+
+// phaseSpan ends the Server-Timing phase along with the span.
+type phaseSpan struct {
+	trace.Span
+	timings *timings
+	once    sync.Once
+}
+
+func (s *phaseSpan) End(opts ...trace.SpanEndOption) {
+	s.once.Do(s.timings.stop)
+	s.Span.End(opts...)
+}
@@ -0,0 +1,355 @@
+// Package upgrade replaces the running binary without refusing connections. The running process starts the new
+// binary with its listening sockets, stops serving once the new process initialized, streams its state to it and
+// exits when the new process serves requests. When the new process fails, the running one serves again.
+package upgrade
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// envListeners names the inherited listeners, colon separated, in the order of their file descriptors.
+const envListeners = "REST_UPGRADE_LISTENERS"
+
+// firstFD is the first file descriptor passed to the new process. The listeners are followed by the control pipe,
+// on which the state is written, and the status pipe, on which the new process reports its progress.
+const firstFD = 3
+
+// Messages reported by the new process, one per line.
+const (
+	msgWaiting = "waiting"
+	msgReady   = "ready"
+)
+
+// Store is state handed over to the new process.
+type Store interface {
+	// Snapshot writes the state to w.
+	Snapshot(w io.Writer) error
+	// Restore replaces the state with one written by Snapshot.
+	Restore(r io.Reader) error
+}
+
+// Handoff describes what is handed over to the new process.
+type Handoff struct {
+	// Listeners are handed over keyed by their role, e.g. api or admin.
+	Listeners map[string][]net.Listener
+	Stores    map[string]Store
+	// Timeout bounds the wait for the new process to initialize, and then to serve.
+	Timeout time.Duration
+	// Quiesce stops serving and returns once the requests in flight finished. It is called once the new process
+	// initialized; afterwards no state may change.
+	Quiesce func()
+	// Resume serves again on the listeners after the new process failed once Quiesce was called.
+	Resume func(lns map[string][]net.Listener)
+}
+
+// Run starts the executable this process was started from, with the same arguments and environment, and hands over
+// to it. A nil error means the new process serves requests and this one should exit without serving again.
+func Run(h Handoff) error {
+	names, files, err := listenerFiles(h.Listeners)
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	c, err := start(names, files)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if err := c.await(msgWaiting, h.Timeout); err != nil {
+		c.kill()
+		return fmt.Errorf("new process did not start: %w", err)
+	}
+
+	// Unix sockets stay in place for the new process when this one stops listening.
+	for _, lns := range h.Listeners {
+		for _, ln := range lns {
+			if ul, ok := ln.(*net.UnixListener); ok {
+				ul.SetUnlinkOnClose(false)
+			}
+		}
+	}
+	h.Quiesce()
+
+	err = c.handoff(h.Stores, h.Timeout)
+	if err == nil {
+		return nil
+	}
+	c.kill()
+
+	lns, rerr := fileListeners(names, files)
+	if rerr != nil {
+		return errors.Join(err, rerr)
+	}
+	h.Resume(lns)
+	return err
+}
+
+// child is the new process, seen from the one handing over.
+type child struct {
+	proc    *os.Process
+	control *os.File
+	status  chan string
+}
+
+// start starts the new process. It forks with syscall.ForkExec rather than os/exec, whose use of File.Fd would put
+// the descriptors in blocking mode, shared with the listeners still accepting in this process.
+func start(names []string, files []*os.File) (*child, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate executable: %w", err)
+	}
+	controlR, controlW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	statusR, statusW, err := os.Pipe()
+	if err != nil {
+		controlR.Close()
+		controlW.Close()
+		return nil, err
+	}
+
+	fds := []uintptr{0, 1, 2}
+	for _, f := range append(slices.Clone(files), controlR, statusW) {
+		fd, ferr := rawFD(f)
+		if ferr != nil {
+			err = ferr
+			break
+		}
+		fds = append(fds, fd)
+	}
+	var pid int
+	if err == nil {
+		env := append(os.Environ(), envListeners+"="+strings.Join(names, ":"))
+		pid, err = syscall.ForkExec(exe, os.Args, &syscall.ProcAttr{Env: env, Files: fds})
+	}
+	controlR.Close()
+	statusW.Close()
+	if err != nil {
+		controlW.Close()
+		statusR.Close()
+		return nil, fmt.Errorf("start %s: %w", exe, err)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		controlW.Close()
+		statusR.Close()
+		return nil, err
+	}
+	slog.Info("new process started", slog.Int("pid", pid), slog.String("path", exe))
+
+	c := &child{proc: proc, control: controlW, status: make(chan string, 2)}
+	go func() {
+		defer statusR.Close()
+		defer close(c.status)
+		s := bufio.NewScanner(statusR)
+		for s.Scan() {
+			c.status <- s.Text()
+		}
+	}()
+	return c, nil
+}
+
+// await waits for the child to report msg. The status pipe closes when the child exits.
+func (c *child) await(msg string, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case got, ok := <-c.status:
+		if !ok {
+			return errors.New("process exited")
+		}
+		if got != msg {
+			return fmt.Errorf("unexpected status %q, want %q", got, msg)
+		}
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("no %s status within %s", msg, timeout)
+	}
+}
+
+// handoff writes the state of the stores to the child and waits until it serves requests.
+func (c *child) handoff(stores map[string]Store, timeout time.Duration) error {
+	state := make(map[string][]byte, len(stores))
+	for name, s := range stores {
+		var buf bytes.Buffer
+		if err := s.Snapshot(&buf); err != nil {
+			return fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		state[name] = buf.Bytes()
+	}
+	if err := gob.NewEncoder(c.control).Encode(state); err != nil {
+		return fmt.Errorf("hand over state: %w", err)
+	}
+	if err := c.await(msgReady, timeout); err != nil {
+		return fmt.Errorf("new process did not become ready: %w", err)
+	}
+	return nil
+}
+
+func (c *child) kill() {
+	_ = c.proc.Kill()
+	_, _ = c.proc.Wait()
+}
+
+func (c *child) close() {
+	c.control.Close()
+}
+
+// Parent is the process handing over to this one.
+type Parent struct {
+	// Listeners are the inherited listeners, keyed by their role.
+	Listeners map[string][]net.Listener
+
+	control *os.File
+	status  *os.File
+}
+
+// Inherited returns the handoff of the process that started this one by Run, nil when this process was started
+// otherwise. The variable describing it is removed from the environment.
+func Inherited() (*Parent, error) {
+	joined, ok := os.LookupEnv(envListeners)
+	if !ok {
+		return nil, nil
+	}
+	os.Unsetenv(envListeners)
+
+	var names []string
+	if joined != "" {
+		names = strings.Split(joined, ":")
+	}
+	for fd := firstFD; fd < firstFD+len(names)+2; fd++ {
+		syscall.CloseOnExec(fd)
+	}
+
+	files := make([]*os.File, len(names))
+	for i, name := range names {
+		files[i] = os.NewFile(uintptr(firstFD+i), name)
+	}
+	lns, err := fileListeners(names, files)
+	closeFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := firstFD + len(names)
+	return &Parent{
+		Listeners: lns,
+		control:   os.NewFile(uintptr(fd), "upgrade control"),
+		status:    os.NewFile(uintptr(fd+1), "upgrade status"),
+	}, nil
+}
+
+// Restore tells the parent this process initialized, waits until the parent stopped serving and restores the
+// stores from the state it hands over.
+func (p *Parent) Restore(stores map[string]Store) error {
+	if err := p.report(msgWaiting); err != nil {
+		return err
+	}
+
+	var state map[string][]byte
+	if err := gob.NewDecoder(p.control).Decode(&state); err != nil {
+		return fmt.Errorf("read handed over state: %w", err)
+	}
+	for name, s := range stores {
+		data, ok := state[name]
+		if !ok {
+			slog.Warn("no state handed over", slog.String("store", name))
+			continue
+		}
+		if err := s.Restore(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Ready tells the parent this process serves requests, after which the parent exits.
+func (p *Parent) Ready() error {
+	defer p.control.Close()
+	defer p.status.Close()
+
+	return p.report(msgReady)
+}
+
+func (p *Parent) report(msg string) error {
+	if _, err := fmt.Fprintln(p.status, msg); err != nil {
+		return fmt.Errorf("report %s to parent: %w", msg, err)
+	}
+	return nil
+}
+
+// listenerFiles duplicates the file descriptors of the listeners, ordered by role.
+func listenerFiles(lns map[string][]net.Listener) ([]string, []*os.File, error) {
+	var names []string
+	var files []*os.File
+	for _, name := range slices.Sorted(maps.Keys(lns)) {
+		for _, ln := range lns[name] {
+			fl, ok := ln.(interface{ File() (*os.File, error) })
+			if !ok {
+				closeFiles(files)
+				return nil, nil, fmt.Errorf("%s listener %s cannot be handed over", name, ln.Addr())
+			}
+			f, err := fl.File()
+			if err != nil {
+				closeFiles(files)
+				return nil, nil, fmt.Errorf("%s listener %s: %w", name, ln.Addr(), err)
+			}
+			names = append(names, name)
+			files = append(files, f)
+		}
+	}
+	return names, files, nil
+}
+
+func fileListeners(names []string, files []*os.File) (map[string][]net.Listener, error) {
+	out := make(map[string][]net.Listener)
+	for i, f := range files {
+		ln, err := net.FileListener(f)
+		if err != nil {
+			for _, lns := range out {
+				for _, l := range lns {
+					l.Close()
+				}
+			}
+			return nil, fmt.Errorf("%s listener: %w", names[i], err)
+		}
+		out[names[i]] = append(out[names[i]], ln)
+	}
+	return out, nil
+}
+
+// rawFD returns the descriptor of f. Unlike f.Fd, it leaves the descriptor in non-blocking mode.
+func rawFD(f *os.File) (uintptr, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var fd uintptr
+	if err := rc.Control(func(d uintptr) { fd = d }); err != nil {
+		return 0, err
+	}
+	return fd, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
@@ -0,0 +1,138 @@
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// envChild selects how the test binary behaves when started by Run.
+const envChild = "UPGRADE_TEST_CHILD"
+
+// TestMain runs the new process started by Run, which is the test binary itself.
+func TestMain(m *testing.M) {
+	if mode, ok := os.LookupEnv(envChild); ok {
+		os.Exit(runChild(mode))
+	}
+	os.Exit(m.Run())
+}
+
+func runChild(mode string) int {
+	if mode == "exit" {
+		return 1
+	}
+	p, err := Inherited()
+	if err != nil || p == nil {
+		return 2
+	}
+	s := &value{}
+	if err := p.Restore(map[string]Store{"value": s}); err != nil {
+		return 3
+	}
+	if mode == "fail" {
+		return 4
+	}
+
+	// Serve a single request, then exit.
+	done := make(chan struct{})
+	srv := &http.Server{ReadHeaderTimeout: time.Second, Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "child %s", s.v)
+		close(done)
+	})}
+	go func() { _ = srv.Serve(p.Listeners["api"][0]) }()
+	if err := p.Ready(); err != nil {
+		return 5
+	}
+	select {
+	case <-done:
+		time.Sleep(100 * time.Millisecond)
+	case <-time.After(10 * time.Second):
+	}
+	return 0
+}
+
+type value struct{ v string }
+
+func (s *value) Snapshot(w io.Writer) error {
+	_, err := io.WriteString(w, s.v)
+	return err
+}
+
+func (s *value) Restore(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	s.v = string(b)
+	return err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		mode        string
+		wantErr     bool
+		wantResumed bool
+		want        string
+	}{
+		{mode: "serve", want: "child handed over"},
+		{mode: "exit", wantErr: true, want: "parent"},
+		{mode: "fail", wantErr: true, wantResumed: true, want: "parent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv(envChild, tt.mode)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr := ln.Addr().String()
+			serve := func(ln net.Listener) *http.Server {
+				srv := &http.Server{ReadHeaderTimeout: time.Second, Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = io.WriteString(w, "parent")
+				})}
+				go func() { _ = srv.Serve(ln) }()
+				return srv
+			}
+			srv := serve(ln)
+
+			resumed := false
+			err = Run(Handoff{
+				Listeners: map[string][]net.Listener{"api": {ln}},
+				Stores:    map[string]Store{"value": &value{v: "handed over"}},
+				Timeout:   5 * time.Second,
+				Quiesce:   func() { _ = srv.Close() },
+				Resume: func(lns map[string][]net.Listener) {
+					resumed = true
+					srv = serve(lns["api"][0])
+				},
+			})
+			defer srv.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resumed != tt.wantResumed {
+				t.Errorf("resumed = %v, want %v", resumed, tt.wantResumed)
+			}
+
+			c := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := c.Get("http://" + addr)
+			if err != nil {
+				t.Fatalf("request after Run() failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("served %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestInheritedWithoutParent(t *testing.T) {
+	p, err := Inherited()
+	if p != nil || err != nil {
+		t.Errorf("Inherited() = %v, %v, want nil", p, err)
+	}
+}
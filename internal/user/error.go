@@ -1,6 +1,9 @@
 package user
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type NotFoundError struct {
 	id int64
@@ -12,8 +15,31 @@ func (e NotFoundError) Error() string {
 
 type InvalidError struct {
 	message string
+	// reason names the failed validation rule for metrics; empty outside of isValidUser.
+	reason string
 }
 
 func (e InvalidError) Error() string {
 	return e.message
 }
+
+type AuthenticationError struct {
+	message string
+}
+
+func (e AuthenticationError) Error() string {
+	return e.message
+}
+
+type LockedError struct {
+	until time.Time
+}
+
+func (e LockedError) Error() string {
+	return "account is temporarily locked after repeated login failures"
+}
+
+// Until reports when the lockout expires.
+func (e LockedError) Until() time.Time {
+	return e.until
+}
@@ -1,10 +1,14 @@
 package user
 
+import "context"
+
 //go:generate mockery --name=Servicer
 type Servicer interface {
-	ListUsers() []User
-	GetUser(id int64) (*User, error)
-	CreateUser(usr *User) (*User, error)
-	UpdateUser(id int64, usr *User) (*User, error)
-	DeleteUser(id int64) error
+	ListUsers(ctx context.Context) []User
+	GetUser(ctx context.Context, id int64) (*User, error)
+	CreateUser(ctx context.Context, usr *User) (*User, error)
+	UpdateUser(ctx context.Context, id int64, usr *User) (*User, error)
+	DeleteUser(ctx context.Context, id int64) error
+	SetPassword(ctx context.Context, id int64, current, password string) error
+	Authenticate(ctx context.Context, email, password string) (*User, error)
 }
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	validationFailures *prometheus.CounterVec
+	count              prometheus.GaugeFunc
+}
+
+func newMetrics(svc *Service) *metrics {
+	return &metrics{
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "rest",
+			Subsystem: "user",
+			Name:      "validation_failures_total",
+			Help:      "Users rejected by validation, by failed rule.",
+		}, []string{"reason"}),
+		count: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "rest",
+			Subsystem: "user",
+			Name:      "users",
+			Help:      "Users currently stored.",
+		}, func() float64 {
+			svc.mu.RLock()
+			defer svc.mu.RUnlock()
+			return float64(len(svc.cache))
+		}),
+	}
+}
+
+// validationFailed counts err when it is a validation failure. Services built without metrics count nothing.
+func (m *metrics) validationFailed(err error) {
+	var inv *InvalidError
+	if m == nil || !errors.As(err, &inv) || inv.reason == "" {
+		return
+	}
+	m.validationFailures.WithLabelValues(inv.reason).Inc()
+}
+
+// Collectors returns the metrics of the service, to be registered with a Prometheus registry.
+func (svc *Service) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{svc.metrics.validationFailures, svc.metrics.count}
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	user "github.com/jqdurham/rest-sample/internal/user"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,9 +14,39 @@ type Servicer struct {
 	mock.Mock
 }
 
-// CreateUser provides a mock function with given fields: usr
-func (_m *Servicer) CreateUser(usr *user.User) (*user.User, error) {
-	ret := _m.Called(usr)
+// Authenticate provides a mock function with given fields: ctx, email, password
+func (_m *Servicer) Authenticate(ctx context.Context, email string, password string) (*user.User, error) {
+	ret := _m.Called(ctx, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 *user.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*user.User, error)); ok {
+		return rf(ctx, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *user.User); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*user.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, usr
+func (_m *Servicer) CreateUser(ctx context.Context, usr *user.User) (*user.User, error) {
+	ret := _m.Called(ctx, usr)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateUser")
@@ -22,19 +54,19 @@ func (_m *Servicer) CreateUser(usr *user.User) (*user.User, error) {
 
 	var r0 *user.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*user.User) (*user.User, error)); ok {
-		return rf(usr)
+	if rf, ok := ret.Get(0).(func(context.Context, *user.User) (*user.User, error)); ok {
+		return rf(ctx, usr)
 	}
-	if rf, ok := ret.Get(0).(func(*user.User) *user.User); ok {
-		r0 = rf(usr)
+	if rf, ok := ret.Get(0).(func(context.Context, *user.User) *user.User); ok {
+		r0 = rf(ctx, usr)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*user.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*user.User) error); ok {
-		r1 = rf(usr)
+	if rf, ok := ret.Get(1).(func(context.Context, *user.User) error); ok {
+		r1 = rf(ctx, usr)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,17 +74,17 @@ func (_m *Servicer) CreateUser(usr *user.User) (*user.User, error) {
 	return r0, r1
 }
 
-// DeleteUser provides a mock function with given fields: id
-func (_m *Servicer) DeleteUser(id int64) error {
-	ret := _m.Called(id)
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *Servicer) DeleteUser(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -60,9 +92,9 @@ func (_m *Servicer) DeleteUser(id int64) error {
 	return r0
 }
 
-// GetUser provides a mock function with given fields: id
-func (_m *Servicer) GetUser(id int64) (*user.User, error) {
-	ret := _m.Called(id)
+// GetUser provides a mock function with given fields: ctx, id
+func (_m *Servicer) GetUser(ctx context.Context, id int64) (*user.User, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUser")
@@ -70,19 +102,19 @@ func (_m *Servicer) GetUser(id int64) (*user.User, error) {
 
 	var r0 *user.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (*user.User, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*user.User, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int64) *user.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *user.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*user.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,17 +122,17 @@ func (_m *Servicer) GetUser(id int64) (*user.User, error) {
 	return r0, r1
 }
 
-// ListUsers provides a mock function with given fields:
-func (_m *Servicer) ListUsers() []user.User {
-	ret := _m.Called()
+// ListUsers provides a mock function with given fields: ctx
+func (_m *Servicer) ListUsers(ctx context.Context) []user.User {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListUsers")
 	}
 
 	var r0 []user.User
-	if rf, ok := ret.Get(0).(func() []user.User); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []user.User); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]user.User)
@@ -110,9 +142,27 @@ func (_m *Servicer) ListUsers() []user.User {
 	return r0
 }
 
-// UpdateUser provides a mock function with given fields: id, usr
-func (_m *Servicer) UpdateUser(id int64, usr *user.User) (*user.User, error) {
-	ret := _m.Called(id, usr)
+// SetPassword provides a mock function with given fields: ctx, id, current, password
+func (_m *Servicer) SetPassword(ctx context.Context, id int64, current string, password string) error {
+	ret := _m.Called(ctx, id, current, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
+		r0 = rf(ctx, id, current, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUser provides a mock function with given fields: ctx, id, usr
+func (_m *Servicer) UpdateUser(ctx context.Context, id int64, usr *user.User) (*user.User, error) {
+	ret := _m.Called(ctx, id, usr)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateUser")
@@ -120,19 +170,19 @@ func (_m *Servicer) UpdateUser(id int64, usr *user.User) (*user.User, error) {
 
 	var r0 *user.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, *user.User) (*user.User, error)); ok {
-		return rf(id, usr)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *user.User) (*user.User, error)); ok {
+		return rf(ctx, id, usr)
 	}
-	if rf, ok := ret.Get(0).(func(int64, *user.User) *user.User); ok {
-		r0 = rf(id, usr)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *user.User) *user.User); ok {
+		r0 = rf(ctx, id, usr)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*user.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, *user.User) error); ok {
-		r1 = rf(id, usr)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *user.User) error); ok {
+		r1 = rf(ctx, id, usr)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,7 +1,24 @@
 package user
 
+// Roles a user may hold, granting the permissions assigned to them by the access policy.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
 type User struct {
 	ID    int64
 	Name  string
 	Email string
+	Role  string
+	// PasswordHash is the PHC encoded argon2id hash of the user's password. It must never leave the service.
+	PasswordHash string `json:"-"`
+}
+
+// Stats summarizes the stored users.
+type Stats struct {
+	Users int `json:"users"`
+	// Locked is the number of accounts currently locked after failed logins.
+	Locked int `json:"locked"`
 }
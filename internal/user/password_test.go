@@ -0,0 +1,143 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPassword_hashAndVerify(t *testing.T) {
+	t.Parallel()
+	hash, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("hashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$") {
+		t.Errorf("hashPassword() = %v, want PHC encoded argon2id hash", hash)
+	}
+
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "Accepts matching password", encoded: hash, password: "correct horse", want: true},
+		{name: "Rejects wrong password", encoded: hash, password: "battery staple"},
+		{name: "Rejects malformed hash", encoded: "$2a$10$notargon", password: "correct horse", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := verifyPassword(tt.encoded, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("verifyPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	t.Parallel()
+	svc := NewService()
+	usr, err := svc.CreateUser(context.Background(), &User{Name: "John Q. Public", Email: "john@public.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if err := svc.SetPassword(context.Background(), usr.ID, "", "correct horse"); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+
+	if got, err := svc.Authenticate(context.Background(), "JOHN@public.com", "correct horse"); err != nil || got.ID != usr.ID {
+		t.Fatalf("Authenticate() = %v, %v, want user %d", got, err, usr.ID)
+	}
+
+	for range maxLoginFailures {
+		var af *AuthenticationError
+		if _, err := svc.Authenticate(context.Background(), "john@public.com", "wrong"); !errors.As(err, &af) {
+			t.Fatalf("Authenticate() error = %v, want AuthenticationError", err)
+		}
+	}
+
+	var lf *LockedError
+	if _, err := svc.Authenticate(context.Background(), "john@public.com", "correct horse"); !errors.As(err, &lf) {
+		t.Errorf("Authenticate() error = %v, want LockedError", err)
+	}
+}
+
+func TestService_AuthenticateConcurrent(t *testing.T) {
+	t.Parallel()
+	svc := NewService()
+	usr, err := svc.CreateUser(context.Background(), &User{Name: "John Q. Public", Email: "john@public.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if err := svc.SetPassword(context.Background(), usr.ID, "", "correct horse"); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+
+	const guesses = 4 * maxLoginFailures
+	var verified atomic.Int64
+	var wg sync.WaitGroup
+	for range guesses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var af *AuthenticationError
+			if _, err := svc.Authenticate(context.Background(), "john@public.com", "wrong"); errors.As(err, &af) {
+				verified.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := verified.Load(); got > maxLoginFailures {
+		t.Errorf("%d of %d concurrent guesses verified, want at most %d", got, guesses, maxLoginFailures)
+	}
+	var lf *LockedError
+	if _, err := svc.Authenticate(context.Background(), "john@public.com", "correct horse"); !errors.As(err, &lf) {
+		t.Errorf("Authenticate() error = %v, want LockedError", err)
+	}
+}
+
+func TestService_pruneFailures(t *testing.T) {
+	t.Parallel()
+	svc := NewService()
+	for _, email := range []string{"nobody@example.com", "someone@example.com"} {
+		if _, err := svc.Authenticate(context.Background(), email, "wrong"); err == nil {
+			t.Fatalf("Authenticate(%q) succeeded", email)
+		}
+	}
+	now := time.Now()
+	svc.failures["locked@example.com"] = &loginFailures{lockedUntil: now.Add(2 * failureWindow)}
+
+	if removed := svc.pruneFailures(now); removed != 0 {
+		t.Errorf("pruneFailures() within the window removed %d, want 0", removed)
+	}
+	if removed := svc.pruneFailures(now.Add(failureWindow + time.Second)); removed != 2 {
+		t.Errorf("pruneFailures() after the window removed %d, want 2", removed)
+	}
+	if _, ok := svc.failures["locked@example.com"]; !ok {
+		t.Error("pruneFailures() removed a lockout in effect")
+	}
+}
+
+func TestLoginFailures_prune(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	f := &loginFailures{times: []time.Time{now.Add(-failureWindow - time.Minute), now.Add(-time.Minute)}}
+	if !f.prune(now) || len(f.times) != 1 {
+		t.Errorf("prune() kept %d failures, want 1", len(f.times))
+	}
+	if f.prune(now.Add(failureWindow)) {
+		t.Error("prune() kept an entry without recent failures or lockout")
+	}
+}
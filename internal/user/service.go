@@ -2,31 +2,101 @@ package user
 
 import (
 	"cmp"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"regexp"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 	"unicode/utf8"
+
+	"github.com/jqdurham/rest-sample/internal/tracing"
 )
 
 // compile time check to make sure Servicer interface is satisfied.
 var _ Servicer = &Service{}
 
+const (
+	// maxLoginFailures is the number of failed logins within failureWindow after which an account is locked.
+	maxLoginFailures = 5
+	// failureWindow is how long a failed login counts towards a lockout.
+	failureWindow = 15 * time.Minute
+	// lockoutDuration is how long an account stays locked after too many failed logins.
+	lockoutDuration = 15 * time.Minute
+)
+
+// dummyHash is verified against when a login names an unknown account so that response timing does not
+// reveal which accounts exist.
+var dummyHash = sync.OnceValue(func() string {
+	h, _ := hashPassword("not a real password")
+	return h
+})
+
+type loginFailures struct {
+	// times are the failed logins within failureWindow, oldest first.
+	times       []time.Time
+	lockedUntil time.Time
+}
+
+// prune drops the failed logins that left the window and reports whether the entry is still needed.
+func (f *loginFailures) prune(now time.Time) bool {
+	cutoff := now.Add(-failureWindow)
+	i := 0
+	for i < len(f.times) && !f.times[i].After(cutoff) {
+		i++
+	}
+	f.times = f.times[i:]
+	return len(f.times) > 0 || now.Before(f.lockedUntil)
+}
+
 type Service struct {
 	mu     sync.RWMutex
 	cache  map[int64]User
 	lastID atomic.Int64
+
+	loginMu  sync.Mutex
+	failures map[string]*loginFailures
+
+	metrics *metrics
 }
 
 func NewService() *Service {
-	return &Service{
-		cache: make(map[int64]User),
+	svc := &Service{
+		cache:    make(map[int64]User),
+		failures: make(map[string]*loginFailures),
+	}
+	svc.metrics = newMetrics(svc)
+	return svc
+}
+
+// Stats summarizes the stored users.
+func (svc *Service) Stats() Stats {
+	svc.mu.RLock()
+	out := Stats{Users: len(svc.cache)}
+	svc.mu.RUnlock()
+
+	svc.loginMu.Lock()
+	defer svc.loginMu.Unlock()
+
+	now := time.Now()
+	for _, f := range svc.failures {
+		if now.Before(f.lockedUntil) {
+			out.Locked++
+		}
 	}
+	return out
 }
 
-func (svc *Service) ListUsers() []User {
+func (svc *Service) ListUsers(ctx context.Context) []User {
+	_, span := tracing.Start(ctx, "user.ListUsers", tracing.PhaseStorage)
+	defer span.End()
+
 	out := make([]User, 0, len(svc.cache))
 
 	svc.mu.RLock()
@@ -43,7 +113,10 @@ func (svc *Service) ListUsers() []User {
 	return out
 }
 
-func (svc *Service) GetUser(id int64) (*User, error) {
+func (svc *Service) GetUser(ctx context.Context, id int64) (*User, error) {
+	_, span := tracing.Start(ctx, "user.GetUser", tracing.PhaseStorage)
+	defer span.End()
+
 	svc.mu.RLock()
 	defer svc.mu.RUnlock()
 
@@ -55,8 +128,15 @@ func (svc *Service) GetUser(id int64) (*User, error) {
 	return &out, nil
 }
 
-func (svc *Service) CreateUser(user *User) (*User, error) {
-	if err := svc.isValidUser(user); err != nil {
+func (svc *Service) CreateUser(ctx context.Context, user *User) (*User, error) {
+	ctx, span := tracing.Start(ctx, "user.CreateUser", tracing.PhaseStorage)
+	defer span.End()
+
+	if user != nil && user.Role == "" {
+		user.Role = RoleViewer
+	}
+
+	if err := svc.isValidUser(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -67,28 +147,41 @@ func (svc *Service) CreateUser(user *User) (*User, error) {
 	svc.cache[id] = *user
 	svc.mu.Unlock()
 
-	return svc.GetUser(user.ID)
+	return svc.GetUser(ctx, user.ID)
 }
 
-func (svc *Service) UpdateUser(id int64, user *User) (*User, error) {
-	if err := svc.isValidUser(user); err != nil {
-		return nil, err
-	}
+// UpdateUser replaces the profile of a user. The role is kept when none is given.
+func (svc *Service) UpdateUser(ctx context.Context, id int64, user *User) (*User, error) {
+	ctx, span := tracing.Start(ctx, "user.UpdateUser", tracing.PhaseStorage)
+	defer span.End()
 
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
-	if _, ok := svc.cache[id]; !ok {
+	stored, ok := svc.cache[id]
+	if !ok {
 		return nil, &NotFoundError{id: id}
 	}
 
+	if user != nil && user.Role == "" {
+		user.Role = stored.Role
+	}
+
+	if err := svc.isValidUser(ctx, user); err != nil {
+		return nil, err
+	}
+
 	user.ID = id
+	user.PasswordHash = stored.PasswordHash
 	svc.cache[id] = *user
 
 	return user, nil
 }
 
-func (svc *Service) DeleteUser(id int64) error {
+func (svc *Service) DeleteUser(ctx context.Context, id int64) error {
+	_, span := tracing.Start(ctx, "user.DeleteUser", tracing.PhaseStorage)
+	defer span.End()
+
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
@@ -101,29 +194,227 @@ func (svc *Service) DeleteUser(id int64) error {
 	return nil
 }
 
-func (svc *Service) isValidUser(user *User) error {
+// SetPassword sets the password of a user. When the user already has a password, current must match it.
+func (svc *Service) SetPassword(ctx context.Context, id int64, current, password string) error {
+	ctx, span := tracing.Start(ctx, "user.SetPassword", tracing.PhaseStorage)
+	defer span.End()
+
+	if err := isValidPassword(password); err != nil {
+		return err
+	}
+
+	usr, err := svc.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if usr.PasswordHash != "" {
+		ok, err := verifyPassword(usr.PasswordHash, current)
+		if err != nil {
+			return fmt.Errorf("verify password: %w", err)
+		}
+		if !ok {
+			return &AuthenticationError{message: "current password is incorrect"}
+		}
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	stored, ok := svc.cache[id]
+	if !ok {
+		return &NotFoundError{id: id}
+	}
+	stored.PasswordHash = hash
+	svc.cache[id] = stored
+
+	return nil
+}
+
+// Authenticate verifies the password of the user owning email. Accounts are locked for a while after
+// repeated failures, regardless of whether the account exists.
+func (svc *Service) Authenticate(ctx context.Context, email, password string) (*User, error) {
+	ctx, span := tracing.Start(ctx, "user.Authenticate", tracing.PhaseStorage)
+	defer span.End()
+
+	key := strings.ToLower(email)
+
+	f, err := svc.reserveLogin(key)
+	if err != nil {
+		return nil, err
+	}
+
+	usr := svc.findByEmail(ctx, email)
+
+	hash := dummyHash()
+	if usr != nil && usr.PasswordHash != "" {
+		hash = usr.PasswordHash
+	}
+
+	ok, err := verifyPassword(hash, password)
+	if err != nil {
+		return nil, fmt.Errorf("verify password: %w", err)
+	}
+
+	svc.loginMu.Lock()
+	defer svc.loginMu.Unlock()
+
+	if !ok || usr == nil || usr.PasswordHash == "" {
+		// The attempt was counted by reserveLogin. A concurrent successful login may have reset the failures since.
+		if svc.failures[key] == f && len(f.times) >= maxLoginFailures {
+			f.times = nil
+			f.lockedUntil = time.Now().Add(lockoutDuration)
+			slog.WarnContext(ctx, "account locked after repeated login failures", slog.Time("until", f.lockedUntil))
+		}
+		return nil, &AuthenticationError{message: "invalid email or password"}
+	}
+
+	delete(svc.failures, key)
+
+	return usr, nil
+}
+
+// reserveLogin counts a login attempt for key as failed before its password is verified, so that concurrent
+// guesses cannot all pass the lockout check. Attempts beyond the limit are refused while earlier ones are
+// still being verified.
+func (svc *Service) reserveLogin(key string) (*loginFailures, error) {
+	svc.loginMu.Lock()
+	defer svc.loginMu.Unlock()
+
+	now := time.Now()
+	f, ok := svc.failures[key]
+	if !ok {
+		f = &loginFailures{}
+		svc.failures[key] = f
+	}
+	f.prune(now)
+	if now.Before(f.lockedUntil) {
+		return nil, &LockedError{until: f.lockedUntil}
+	}
+	if len(f.times) >= maxLoginFailures {
+		return nil, &LockedError{until: now.Add(lockoutDuration)}
+	}
+	f.times = append(f.times, now)
+	return f, nil
+}
+
+// Run removes login failures that no longer count towards a lockout every interval until ctx is done, so that
+// attempts for unknown or idle accounts do not accumulate.
+func (svc *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if removed := svc.pruneFailures(now); removed > 0 {
+				slog.Debug("expired login failures removed", slog.Int("count", removed))
+			}
+		}
+	}
+}
+
+func (svc *Service) pruneFailures(now time.Time) int {
+	svc.loginMu.Lock()
+	defer svc.loginMu.Unlock()
+
+	removed := 0
+	for k, f := range svc.failures {
+		if !f.prune(now) {
+			delete(svc.failures, k)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (svc *Service) findByEmail(ctx context.Context, email string) *User {
+	for _, usr := range svc.ListUsers(ctx) {
+		if strings.EqualFold(usr.Email, email) {
+			return &usr
+		}
+	}
+	return nil
+}
+
+func isValidPassword(password string) error {
+	passwordLen := utf8.RuneCountInString(password)
+	if passwordLen < 8 || passwordLen > 1024 {
+		return &InvalidError{message: "password must be between 8 and 1024 characters in length"}
+	}
+	return nil
+}
+
+func (svc *Service) isValidUser(ctx context.Context, user *User) (err error) {
+	ctx, span := tracing.Start(ctx, "user.isValidUser", tracing.PhaseValidation)
+	defer span.End()
+
 	defer func(start time.Time) {
-		slog.Debug("validating user", slog.Duration("dur", time.Since(start)))
+		slog.DebugContext(ctx, "validating user", slog.Duration("dur", time.Since(start)))
+		svc.metrics.validationFailed(err)
 	}(time.Now())
 
 	if user == nil {
-		return &InvalidError{message: "user missing"}
+		return &InvalidError{message: "user missing", reason: "missing"}
 	}
 
 	nameLen := utf8.RuneCountInString(user.Name)
 	if nameLen < 3 || nameLen > 5000 {
-		return &InvalidError{message: "name must be between 3 and 5000 characters in length"}
+		return &InvalidError{message: "name must be between 3 and 5000 characters in length", reason: "name_length"}
 	}
 
 	emailLen := utf8.RuneCountInString(user.Email)
 	if emailLen < 2 || emailLen > 200 {
-		return &InvalidError{message: "email must be between 3 and 200 characters in length"}
+		return &InvalidError{message: "email must be between 3 and 200 characters in length", reason: "email_length"}
+	}
+
+	switch user.Role {
+	case RoleAdmin, RoleEditor, RoleViewer:
+	default:
+		return &InvalidError{message: "role must be one of admin, editor or viewer", reason: "role"}
 	}
 
 	emailRE := regexp.MustCompile(`\w+([-+.']\w+)*@\w+([-.]\w+)*\.\w+([-.]\w+)*`)
 	if !emailRE.MatchString(user.Email) {
-		return &InvalidError{message: "email appears to be invalid"}
+		return &InvalidError{message: "email appears to be invalid", reason: "email_format"}
 	}
 
 	return nil
 }
+
+// snapshot is the state handed to a process replacing this one. Login failures are not kept.
+type snapshot struct {
+	Users  map[int64]User
+	LastID int64
+}
+
+// Snapshot writes the stored users, password hashes included, to w.
+func (svc *Service) Snapshot(w io.Writer) error {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	return gob.NewEncoder(w).Encode(snapshot{Users: svc.cache, LastID: svc.lastID.Load()})
+}
+
+// Restore replaces the stored users with those written by Snapshot.
+func (svc *Service) Restore(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode users: %w", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.cache = make(map[int64]User, len(snap.Users))
+	maps.Copy(svc.cache, snap.Users)
+	svc.lastID.Store(snap.LastID)
+	return nil
+}